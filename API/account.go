@@ -0,0 +1,105 @@
+package API
+
+import (
+	"context"
+	"fmt"
+	"github.com/LucaPaterlini/infura/config"
+	"github.com/LucaPaterlini/infura/dataCollection"
+	"github.com/LucaPaterlini/infura/middlewares/cache"
+	"github.com/gorilla/mux"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// accountRequest parses and validates the address and the block of the account endpoints, writing the error when
+// they are not valid. The tags but pending are resolved to the number of their block, reported by the header X-Block-Number,
+// so that the state is read at the block reported, while the returned ttl is still the one of the tag.
+func (h *Handler) accountRequest(ctx context.Context, w http.ResponseWriter, r *http.Request) (address string, block dataCollection.BlockRef, ttl time.Duration, ok bool) {
+	address = mux.Vars(r)["address"]
+	if err := checkAddress(address); err != nil {
+		writeError(err, http.StatusBadRequest, w)
+		return
+	}
+	block, err := blockRef(r.URL.Query().Get("block"), h.heads.Latest())
+	if err != nil {
+		writeError(err, http.StatusBadRequest, w)
+		return
+	}
+	ttl = h.blockRefTTL(block)
+	if block.Tag == "" {
+		return address, block, ttl, true
+	}
+	blockNumber, err := h.heads.Resolve(ctx, block.Tag)
+	if err != nil {
+		writeError(err, errorStatus(err), w)
+		return
+	}
+	w.Header().Set(cache.BlockNumberHeader, strconv.FormatUint(blockNumber, 10))
+	if block.Tag != dataCollection.TagPending {
+		block = dataCollection.BlockNumberRef(blockNumber)
+	}
+	return address, block, ttl, true
+}
+
+// writeAccountResult writes the state of the account, cached for the given ttl.
+func writeAccountResult(result interface{}, err error, ttl time.Duration, w http.ResponseWriter) {
+	if err == nil {
+		setCacheTTL(w, ttl)
+	}
+	writeResult(result, err, w)
+}
+
+// GetBalanceHandler is the handler that retrieves the balance in wei of an account at the block of the query param block,
+// a number, a block hash or a tag, by default the latest block.
+func (h *Handler) GetBalanceHandler(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), config.DefaultRequestsTimeout)
+	defer cancel()
+	address, block, ttl, ok := h.accountRequest(ctx, w, r)
+	if !ok {
+		return
+	}
+	balance, err := h.client.GetBalance(ctx, address, block)
+	writeAccountResult(balance, err, ttl, w)
+}
+
+// GetNonceHandler is the handler that retrieves the number of transactions sent by an account at the block of the query param block.
+func (h *Handler) GetNonceHandler(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), config.DefaultRequestsTimeout)
+	defer cancel()
+	address, block, ttl, ok := h.accountRequest(ctx, w, r)
+	if !ok {
+		return
+	}
+	nonce, err := h.client.GetTransactionCount(ctx, address, block)
+	writeAccountResult(dataCollection.Uint64(nonce), err, ttl, w)
+}
+
+// GetCodeHandler is the handler that retrieves the code deployed at an account at the block of the query param block.
+func (h *Handler) GetCodeHandler(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), config.DefaultRequestsTimeout)
+	defer cancel()
+	address, block, ttl, ok := h.accountRequest(ctx, w, r)
+	if !ok {
+		return
+	}
+	code, err := h.client.GetCode(ctx, address, block)
+	writeAccountResult(code, err, ttl, w)
+}
+
+// GetStorageHandler is the handler that retrieves the value of a storage slot of an account at the block of the query param block.
+func (h *Handler) GetStorageHandler(w http.ResponseWriter, r *http.Request) {
+	slot := mux.Vars(r)["slot"]
+	if !isSlot(slot) {
+		writeError(fmt.Errorf("invalid storage slot %q", slot), http.StatusBadRequest, w)
+		return
+	}
+	ctx, cancel := context.WithTimeout(r.Context(), config.DefaultRequestsTimeout)
+	defer cancel()
+	address, block, ttl, ok := h.accountRequest(ctx, w, r)
+	if !ok {
+		return
+	}
+	value, err := h.client.GetStorageAt(ctx, address, slot, block)
+	writeAccountResult(value, err, ttl, w)
+}
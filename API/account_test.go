@@ -0,0 +1,27 @@
+package API
+
+import "testing"
+
+func TestGetBalanceHandler(t *testing.T) {
+	h, heads := newTestHandler(t)
+	defer heads.Stop()
+	testHandler(t, h.GetBalanceHandler, testCasesGetBalance)
+}
+
+func TestGetNonceHandler(t *testing.T) {
+	h, heads := newTestHandler(t)
+	defer heads.Stop()
+	testHandler(t, h.GetNonceHandler, testCasesGetNonce)
+}
+
+func TestGetCodeHandler(t *testing.T) {
+	h, heads := newTestHandler(t)
+	defer heads.Stop()
+	testHandler(t, h.GetCodeHandler, testCasesGetCode)
+}
+
+func TestGetStorageHandler(t *testing.T) {
+	h, heads := newTestHandler(t)
+	defer heads.Stop()
+	testHandler(t, h.GetStorageHandler, testCasesGetStorage)
+}
@@ -0,0 +1,232 @@
+package API
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/LucaPaterlini/infura/config"
+	"github.com/LucaPaterlini/infura/dataCollection"
+	"github.com/LucaPaterlini/infura/middlewares/cache"
+	"github.com/gorilla/mux"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// blockView is the representation of a block requested by the query params transactions and view.
+type blockView int
+
+// The representations of a block, from the heaviest to the lightest.
+const (
+	viewHashes blockView = iota
+	viewFull
+	viewHeader
+)
+
+// parseBlockView parses the query params ?transactions=hashes|full and ?view=header, by default the hashes of the transactions.
+func parseBlockView(query url.Values) (blockView, error) {
+	view := viewHashes
+	switch transactions := query.Get("transactions"); transactions {
+	case "", "hashes":
+	case "full":
+		view = viewFull
+	default:
+		return view, fmt.Errorf("invalid transactions %q", transactions)
+	}
+	switch value := query.Get("view"); value {
+	case "":
+	case "header":
+		if view == viewFull {
+			return view, fmt.Errorf("the header view has no transactions")
+		}
+		view = viewHeader
+	default:
+		return view, fmt.Errorf("invalid view %q", value)
+	}
+	return view, nil
+}
+
+// GetBlockByHashHandler is the handler that retrieves a block by its hash, accepting the same query params
+// of the GetBlockHandler. Once the number of the block is known the block is shared with the requests by number,
+// the blocks unknown or not in the canonical chain anymore are not found.
+func (h *Handler) GetBlockByHashHandler(w http.ResponseWriter, r *http.Request) {
+	hash := mux.Vars(r)["blockHash"]
+	if !isHash(hash) {
+		writeError(fmt.Errorf("invalid block hash %q", hash), http.StatusBadRequest, w)
+		return
+	}
+	view, err := parseBlockView(r.URL.Query())
+	if err != nil {
+		writeError(err, http.StatusBadRequest, w)
+		return
+	}
+	ctx, cancel := context.WithTimeout(r.Context(), config.DefaultRequestsTimeout)
+	defer cancel()
+	blockID, known := h.storedBlockNumber(hash)
+	if !known {
+		header, err := h.client.GetBlockByHash(ctx, hash)
+		if err != nil || header == nil {
+			writeBlockNotFound(hash, err, w)
+			return
+		}
+		blockID = uint64(header.Number)
+	}
+	// the block by hash is served only while it is the canonical block at its number
+	block, err := h.getBlock(ctx, blockID, view == viewFull)
+	if err != nil || block == nil || !strings.EqualFold(block.Hash, hash) {
+		writeBlockNotFound(hash, err, w)
+		return
+	}
+	h.writeBlockResult(viewOf(block, view), true, nil, blockID, "", w)
+}
+
+// storedBlockNumber returns the number of the block with the given hash, when it is known.
+func (h *Handler) storedBlockNumber(hash string) (uint64, bool) {
+	if h.store == nil {
+		return 0, false
+	}
+	value, ok := h.store.Get(hashKey(hash))
+	if !ok {
+		return 0, false
+	}
+	blockID, err := strconv.ParseUint(string(value), 10, 64)
+	return blockID, err == nil
+}
+
+// writeBlockNotFound writes the error of the lookup by hash, or that the block is not found when there was no error.
+func writeBlockNotFound(hash string, err error, w http.ResponseWriter) {
+	if err != nil {
+		writeError(err, errorStatus(err), w)
+		return
+	}
+	writeError(fmt.Errorf("block %s not found", hash), http.StatusNotFound, w)
+}
+
+// getBlock gets the block with the full transactions or their hashes, reading and saving it in the store, when not nil,
+// as long as the responses are cached. The blocks retrieved with the full transactions satisfy the requests
+// for their hashes too, so that the lighter representations are derived without requesting the block again.
+func (h *Handler) getBlock(ctx context.Context, blockID uint64, full bool) (*dataCollection.Block, error) {
+	if block, ok := h.storedBlock(blockID, full); ok {
+		return block, nil
+	}
+	var block *dataCollection.Block
+	var err error
+	if full {
+		block, err = h.client.GetFullBlock(ctx, blockID)
+	} else {
+		block, err = h.client.GetBlock(ctx, blockID)
+	}
+	if err == nil {
+		h.saveBlock(blockID, full, block)
+	}
+	return block, err
+}
+
+// saveBlock saves the block in the store, when not nil, as long as the responses of the block are cached,
+// indefinitely once it can not change anymore and for about a block while it is near the head.
+func (h *Handler) saveBlock(blockID uint64, full bool, block *dataCollection.Block) {
+	if block == nil || h.store == nil {
+		return
+	}
+	value, _ := json.Marshal(block)
+	blockNumber := dataCollection.Uint64(blockID)
+	expiration := time.Now().Add(h.confirmedTTL(&blockNumber))
+	h.store.Set(blockKey(blockID, full), value, expiration)
+	// the lookups by hash of the same block find it by its number
+	h.store.Set(hashKey(block.Hash), []byte(strconv.FormatUint(blockID, 10)), expiration)
+}
+
+// Evict releases from the store the blocks orphaned by the reorg and the lookups of their hashes,
+// so that the blocks that replaced them are requested again.
+func (h *Handler) Evict(reorg dataCollection.Reorg) {
+	if h.store == nil {
+		return
+	}
+	for i, blockID := range reorg.Numbers {
+		h.store.Release(blockKey(blockID, true))
+		h.store.Release(blockKey(blockID, false))
+		h.store.Release(hashKey(reorg.Hashes[i]))
+	}
+}
+
+// storedBlock returns the block saved in the store, with the full transactions when requested,
+// otherwise with their hashes even when it was saved with the full transactions.
+func (h *Handler) storedBlock(blockID uint64, full bool) (*dataCollection.Block, bool) {
+	if h.store == nil {
+		return nil, false
+	}
+	value, ok := h.store.Get(blockKey(blockID, true))
+	if !ok && !full {
+		value, ok = h.store.Get(blockKey(blockID, false))
+	}
+	if !ok {
+		return nil, false
+	}
+	block := decodeBlock(value, full)
+	return block, block != nil
+}
+
+// storedBlocks returns the blocks from-to saved in the store as storedBlock, nil for the missing ones,
+// reading each variant of the blocks with a single round trip when the store supports it.
+func (h *Handler) storedBlocks(from, to uint64, full bool) []*dataCollection.Block {
+	blocks := make([]*dataCollection.Block, to-from+1)
+	if h.store == nil {
+		return blocks
+	}
+	for _, variant := range []bool{true, false} {
+		if full && !variant {
+			break
+		}
+		var keys []string
+		var blockIDs []uint64
+		for blockID := from; blockID <= to; blockID++ {
+			if blocks[blockID-from] == nil {
+				keys, blockIDs = append(keys, blockKey(blockID, variant)), append(blockIDs, blockID)
+			}
+		}
+		if len(keys) == 0 {
+			break
+		}
+		for i, value := range cache.GetMulti(h.store, keys...) {
+			if value != nil {
+				blocks[blockIDs[i]-from] = decodeBlock(value, full)
+			}
+		}
+	}
+	return blocks
+}
+
+// decodeBlock decodes the block saved in the store, with the hashes of its transactions unless full, nil when invalid.
+func decodeBlock(value []byte, full bool) *dataCollection.Block {
+	block := new(dataCollection.Block)
+	if err := json.Unmarshal(value, block); err != nil {
+		return nil
+	}
+	if !full {
+		block = block.WithHashes()
+	}
+	return block
+}
+
+// blockKey returns the key of the block in the store.
+func blockKey(blockID uint64, full bool) string {
+	if full {
+		return "block:full:" + strconv.FormatUint(blockID, 10)
+	}
+	return "block:hashes:" + strconv.FormatUint(blockID, 10)
+}
+
+// hashKey returns the key in the store of the number of the block with the given hash.
+func hashKey(hash string) string {
+	return "block:hash:" + strings.ToLower(hash)
+}
+
+// viewOf returns the block in the requested representation.
+func viewOf(block *dataCollection.Block, view blockView) interface{} {
+	if view == viewHeader && block != nil {
+		return block.Header()
+	}
+	return block
+}
@@ -0,0 +1,99 @@
+package API
+
+import (
+	"context"
+	"github.com/LucaPaterlini/infura/dataCollection"
+	"github.com/gorilla/mux"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestGetBlockHandler_views(t *testing.T) {
+	h, heads := newTestHandler(t)
+	defer heads.Stop()
+	testHandler(t, h.GetBlockHandler, testCasesGetBlockViews)
+}
+
+// getBlock serves the request of the block at path with the handler.
+func getBlock(h *Handler, path string) *httptest.ResponseRecorder {
+	router := mux.NewRouter()
+	router.HandleFunc("/v1/{blockId:[0-9]+}", h.GetBlockHandler)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+	return w
+}
+
+func TestGetBlockHandler_fullBlockStore(t *testing.T) {
+	client := dataCollection.NewClient(fakeUpstream{})
+	heads := newTestHeadTracker(t, client)
+	defer heads.Stop()
+	store := &mapStore{values: make(map[string][]byte)}
+	if w := getBlock(NewHandler(client, heads, store), "/v1/12?transactions=full"); w.Code != http.StatusOK || store.values[blockKey(12, true)] == nil {
+		t.Fatalf("Expected: the full block stored got : %d %d", w.Code, len(store.values))
+	}
+
+	// the lighter views are derived from the stored block without reaching the upstream
+	h := NewHandler(dataCollection.NewClient(failingUpstream{statusCode: http.StatusInternalServerError}), heads, store)
+	for path, expected := range map[string][]byte{
+		"/v1/12":             []byte(recordedBlock12),
+		"/v1/12?view=header": testCasesGetBlockViews[1].expectedBodyBytes,
+	} {
+		if w := getBlock(h, path); w.Code != http.StatusOK || w.Body.String() != string(expected) {
+			t.Errorf("%s Expected: %s got : %d %s", path, expected, w.Code, w.Body)
+		}
+	}
+}
+
+func TestGetBlockByHashHandler(t *testing.T) {
+	h, heads := newTestHandler(t)
+	defer heads.Stop()
+	testHandler(t, h.GetBlockByHashHandler, testCasesGetBlockByHash)
+}
+
+func TestGetBlockByHashHandler_store(t *testing.T) {
+	client := dataCollection.NewClient(fakeUpstream{})
+	heads := newTestHeadTracker(t, client)
+	defer heads.Stop()
+	store := &mapStore{values: make(map[string][]byte)}
+	if w := getBlock(NewHandler(client, heads, store), "/v1/12"); w.Code != http.StatusOK {
+		t.Fatalf("Expected: 200 got : %d", w.Code)
+	}
+
+	// the block requested by number is found by its hash without reaching the upstream
+	h := NewHandler(dataCollection.NewClient(failingUpstream{statusCode: http.StatusInternalServerError}), heads, store)
+	router := mux.NewRouter()
+	router.HandleFunc("/v1/block/hash/{blockHash}", h.GetBlockByHashHandler)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/v1/block/hash/0xc63f666315fa1eae17e354fab532aeeecf549be93e358737d0648f50d57083a0", nil))
+	if w.Code != http.StatusOK || w.Body.String() != recordedBlock12 {
+		t.Errorf("Expected: %s got : %d %s", recordedBlock12, w.Code, w.Body)
+	}
+}
+
+func TestGetBlockHandler_tags(t *testing.T) {
+	h, heads := newTestHandler(t)
+	defer heads.Stop()
+	testHandler(t, h.GetBlockHandler, testCasesGetBlockTags)
+}
+
+func TestHandler_Evict(t *testing.T) {
+	h, heads := newTestHandler(t)
+	defer heads.Stop()
+	store := &mapStore{values: make(map[string][]byte)}
+	h.store = store
+	if _, err := h.getBlock(context.Background(), 12, true); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := h.getBlock(context.Background(), 8373417, false); err != nil {
+		t.Fatal(err)
+	}
+	h.Evict(dataCollection.Reorg{Numbers: []uint64{12}, Hashes: []string{"0xc63f666315fa1eae17e354fab532aeeecf549be93e358737d0648f50d57083a0"}})
+	// only the reorged block and the lookup of its hash are released
+	if len(store.values) != 2 {
+		t.Errorf("Expected: the 2 keys of the block 8373417 got : %v", store.values)
+	}
+	if _, ok := store.values[blockKey(8373417, false)]; !ok {
+		t.Errorf("Expected: the block 8373417 kept got : %v", store.values)
+	}
+}
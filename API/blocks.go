@@ -0,0 +1,151 @@
+package API
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/LucaPaterlini/infura/config"
+	"github.com/LucaPaterlini/infura/dataCollection"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// GetBlocksHandler is the handler that streams the blocks in the range from-to, accepting the same query params
+// of the GetBlockHandler, as a JSON array or, with ?format=ndjson or the Accept header application/x-ndjson,
+// as one block per line. The range is served in chunks of config.BlocksBatchSize blocks, the ones cached are taken
+// from the store and the missing ones of each chunk are requested with a single batch request,
+// so that large ranges are neither requested one block at a time nor buffered.
+func (h *Handler) GetBlocksHandler(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	from, to, err := h.blocksRange(query)
+	if err != nil {
+		writeError(err, http.StatusBadRequest, w)
+		return
+	}
+	view, err := parseBlockView(query)
+	if err != nil {
+		writeError(err, http.StatusBadRequest, w)
+		return
+	}
+	ndjson := query.Get("format") == "ndjson" || strings.Contains(r.Header.Get("Accept"), "application/x-ndjson")
+	ctx, cancel := context.WithTimeout(r.Context(), config.BlocksRequestsTimeout)
+	defer cancel()
+
+	flusher, _ := w.(http.Flusher)
+	for start := from; ; start += config.BlocksBatchSize {
+		end := start + config.BlocksBatchSize - 1
+		if end > to || end < start {
+			end = to
+		}
+		blocks, err := h.getBlocks(ctx, start, end, view == viewFull)
+		if err != nil {
+			writeBlocksError(err, start == from, w)
+			return
+		}
+		if start == from {
+			writeBlocksHeader(w, ndjson)
+		}
+		writeBlocks(w, blocks, view, ndjson, start == from)
+		if flusher != nil {
+			flusher.Flush()
+		}
+		if end == to {
+			break
+		}
+	}
+	if !ndjson {
+		_, _ = w.Write([]byte("]"))
+	}
+}
+
+// writeBlocksHeader writes the header of the stream of blocks, that is never cached as a whole.
+func writeBlocksHeader(w http.ResponseWriter, ndjson bool) {
+	w.Header().Set("Cache-Control", "no-store")
+	if ndjson {
+		w.Header().Set("Content-Type", "application/x-ndjson")
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write([]byte("["))
+}
+
+// writeBlocksError writes the error of the first chunk of blocks, or aborts the response already started
+// by the previous chunks, so that the client does not take it as complete.
+func writeBlocksError(err error, first bool, w http.ResponseWriter) {
+	if first {
+		writeError(err, errorStatus(err), w)
+		return
+	}
+	log.Println(err.Error())
+	panic(http.ErrAbortHandler)
+}
+
+// writeBlocks writes a chunk of blocks in the view requested, one per line or as the elements of the JSON array
+// separated from the ones of the previous chunks unless it is the first one.
+func writeBlocks(w http.ResponseWriter, blocks []*dataCollection.Block, view blockView, ndjson, first bool) {
+	for i, block := range blocks {
+		line, _ := json.Marshal(viewOf(block, view))
+		switch {
+		case ndjson:
+			line = append(line, '\n')
+		case !first || i > 0:
+			line = append([]byte(","), line...)
+		}
+		_, _ = w.Write(line)
+	}
+}
+
+// blocksRange parses and validates the query params from and to of the GetBlocksHandler, by default the last block.
+func (h *Handler) blocksRange(query url.Values) (from, to uint64, err error) {
+	latest := h.heads.Latest()
+	to, from = latest, latest
+	if value := query.Get("to"); value != "" {
+		if to, err = strconv.ParseUint(value, 10, 64); err != nil {
+			return 0, 0, fmt.Errorf("invalid to %q", value)
+		}
+		from = to
+	}
+	if value := query.Get("from"); value != "" {
+		if from, err = strconv.ParseUint(value, 10, 64); err != nil {
+			return 0, 0, fmt.Errorf("invalid from %q", value)
+		}
+	}
+	switch {
+	case to > latest:
+		return 0, 0, fmt.Errorf("requested id %d latest %d", to, latest)
+	case from > to:
+		return 0, 0, fmt.Errorf("from %d after to %d", from, to)
+	case to-from >= config.BlocksMaxRange:
+		return 0, 0, fmt.Errorf("range of %d blocks larger than %d", to-from+1, config.BlocksMaxRange)
+	}
+	return from, to, nil
+}
+
+// getBlocks gets the blocks from-to, taking the ones cached from the store with a single round trip
+// and requesting the missing ones with a single batch request.
+func (h *Handler) getBlocks(ctx context.Context, from, to uint64, full bool) ([]*dataCollection.Block, error) {
+	blocks := h.storedBlocks(from, to, full)
+	var missing []uint64
+	for blockID := from; blockID <= to; blockID++ {
+		if blocks[blockID-from] == nil {
+			missing = append(missing, blockID)
+		}
+	}
+	if len(missing) == 0 {
+		return blocks, nil
+	}
+	fetched, err := h.client.GetBlocks(ctx, missing, full)
+	if err != nil {
+		return nil, err
+	}
+	for i, blockID := range missing {
+		blocks[blockID-from] = fetched[i]
+		h.saveBlock(blockID, full, fetched[i])
+	}
+	return blocks, nil
+}
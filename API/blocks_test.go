@@ -0,0 +1,71 @@
+package API
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/LucaPaterlini/infura/dataCollection"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+)
+
+func TestGetBlocksHandler(t *testing.T) {
+	h, heads := newTestHandler(t)
+	defer heads.Stop()
+	testHandler(t, h.GetBlocksHandler, testCasesGetBlocks)
+}
+
+// countingUpstream is the fakeUpstream that counts the requests received, failing the ones after the first limit.
+type countingUpstream struct {
+	fakeUpstream
+	calls *int64
+	limit int64
+}
+
+func (u countingUpstream) Post(ctx context.Context, jsonStr []byte) (int, map[string][]string, []byte, error) {
+	if atomic.AddInt64(u.calls, 1) > u.limit {
+		return http.StatusInternalServerError, nil, nil, nil
+	}
+	return u.fakeUpstream.Post(ctx, jsonStr)
+}
+
+func TestGetBlocksHandler_batches(t *testing.T) {
+	heads := newTestHeadTracker(t, dataCollection.NewClient(fakeUpstream{}))
+	defer heads.Stop()
+	var calls int64
+	client := dataCollection.NewClient(countingUpstream{calls: &calls, limit: 3})
+	client.Retry.MaxAttempts = 1
+	store := &mapStore{values: make(map[string][]byte)}
+	h := NewHandler(client, heads, store)
+	ts := httptest.NewServer(http.HandlerFunc(h.GetBlocksHandler))
+	defer ts.Close()
+
+	// 60 blocks are requested with a batch of 50 blocks and a batch of 10
+	resp, err := http.Get(ts.URL + "/v1/blocks?from=12&to=71")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var blocks []*dataCollection.Block
+	err = json.NewDecoder(resp.Body).Decode(&blocks)
+	resp.Body.Close()
+	if err != nil || len(blocks) != 60 || blocks[0] == nil || blocks[0].Number != 12 || calls != 2 {
+		t.Errorf("Expected: 60 blocks in 2 calls got : %d blocks in %d calls %v", len(blocks), calls, err)
+	}
+
+	// the block cached is served from the store, while a failure after the start of the response aborts it
+	resp, err = http.Get(ts.URL + "/v1/blocks?from=12&to=12")
+	if err != nil || resp.StatusCode != http.StatusOK || atomic.LoadInt64(&calls) != 2 {
+		t.Fatalf("Expected: 200 without calls got : %v %v", resp, err)
+	}
+	resp.Body.Close()
+	resp, err = http.Get(ts.URL + "/v1/blocks?from=0&to=60")
+	if err == nil {
+		_, err = ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+	}
+	if err == nil {
+		t.Error("Expected: the response aborted got : nil")
+	}
+}
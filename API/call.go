@@ -0,0 +1,77 @@
+package API
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/LucaPaterlini/infura/config"
+	"github.com/LucaPaterlini/infura/dataCollection"
+	"net/http"
+	"strings"
+)
+
+// callRequest is the body of the call requests, the block is a number, a block hash or a tag, by default the latest block.
+type callRequest struct {
+	From  string                   `json:"from"`
+	To    string                   `json:"to"`
+	Gas   *dataCollection.Uint64   `json:"gas"`
+	Value *dataCollection.Quantity `json:"value"`
+	Data  string                   `json:"data"`
+	Block string                   `json:"block"`
+}
+
+// CallHandler is the handler that executes a read only contract call at the block of the body and returns its output.
+// The outputs of the calls at a block that can not change anymore are cached permanently, keyed by the call and the block,
+// the ones at the blocks that can still change are always requested to the third party api.
+func (h *Handler) CallHandler(w http.ResponseWriter, r *http.Request) {
+	msg, block, err := h.callRequest(w, r)
+	if err != nil {
+		writeError(err, http.StatusBadRequest, w)
+		return
+	}
+	ttl := h.blockRefTTL(block)
+	var store dataCollection.ChunkStore
+	if ttl == config.CacheImmutableTime {
+		store = h.store
+	}
+	ctx, cancel := context.WithTimeout(r.Context(), config.DefaultRequestsTimeout)
+	defer cancel()
+	output, err := h.client.Call(ctx, msg, block, store)
+	if err == nil {
+		setCacheTTL(w, ttl)
+	}
+	writeResult(output, err, w)
+}
+
+// callRequest decodes and validates the body of the CallHandler.
+func (h *Handler) callRequest(w http.ResponseWriter, r *http.Request) (msg dataCollection.CallMsg, block dataCollection.BlockRef, err error) {
+	var req callRequest
+	decoder := json.NewDecoder(http.MaxBytesReader(w, r.Body, config.CallMaxBodySize))
+	decoder.DisallowUnknownFields()
+	if err = decoder.Decode(&req); err != nil {
+		return msg, block, fmt.Errorf("invalid call: %s", err)
+	}
+	if err = checkAddress(req.To); err != nil {
+		return msg, block, err
+	}
+	if req.From != "" {
+		if err = checkAddress(req.From); err != nil {
+			return msg, block, err
+		}
+	}
+	if req.Data != "" && !isData(req.Data) {
+		return msg, block, fmt.Errorf("invalid data %q", req.Data)
+	}
+	if block, err = blockRef(req.Block, h.heads.Latest()); err != nil {
+		return msg, block, err
+	}
+	// the same call is cached once however its addresses and data are cased
+	msg = dataCollection.CallMsg{
+		From:  strings.ToLower(req.From),
+		To:    strings.ToLower(req.To),
+		Gas:   req.Gas,
+		Value: req.Value,
+		Data:  strings.ToLower(req.Data),
+	}
+	return msg, block, nil
+}
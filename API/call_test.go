@@ -0,0 +1,42 @@
+package API
+
+import (
+	"bytes"
+	"github.com/LucaPaterlini/infura/dataCollection"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestCallHandler(t *testing.T) {
+	h, heads := newTestHandler(t)
+	defer heads.Stop()
+	testHandler(t, h.CallHandler, testCasesCall)
+}
+
+func TestCallHandler_store(t *testing.T) {
+	client := dataCollection.NewClient(fakeUpstream{})
+	heads := newTestHeadTracker(t, client)
+	defer heads.Stop()
+	store := &mapStore{values: make(map[string][]byte)}
+	h := NewHandler(client, heads, store)
+
+	for _, block := range []string{"12", "latest"} {
+		body := `{"to":"0xdac17f958d2ee523a2206206994597c13d831ec7","data":"0x18160ddd","block":"` + block + `"}`
+		w := httptest.NewRecorder()
+		h.CallHandler(w, httptest.NewRequest(http.MethodPost, "/v1/call", strings.NewReader(body)))
+		if w.Code != http.StatusOK {
+			t.Errorf("block %s Expected: 200 got : %d", block, w.Code)
+		}
+	}
+	// only the call at the confirmed block is stored
+	if len(store.values) != 1 {
+		t.Errorf("Expected: 1 call stored got : %d", len(store.values))
+	}
+	for key, value := range store.values {
+		if !strings.Contains(key, `"0xc"`) || !bytes.HasPrefix(value, []byte("0x")) {
+			t.Errorf("unexpected stored call %s %s", key, value)
+		}
+	}
+}
@@ -2,98 +2,312 @@
 package API
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"github.com/LucaPaterlini/infura/config"
 	"github.com/LucaPaterlini/infura/dataCollection"
+	"github.com/LucaPaterlini/infura/middlewares/cache"
 	"github.com/gorilla/mux"
 	"log"
 	"net/http"
-	"runtime/debug"
 	"strconv"
-	"sync"
-	"sync/atomic"
+	"strings"
 	"time"
 )
 
-var lastBlock uint64
-
-// UpdateRoutine updates the value of the last block atomically avery freq interval, with a set timeout.
-func UpdateRoutine(freq, timeout time.Duration) {
-	ticker := time.NewTicker(freq)
-	// new request every config.DefaultRequestsTimeout time
-	wg := sync.WaitGroup{}
-	wg.Add(1)
-	go func() {
-		confirm := 1
-		for ; true; <-ticker.C {
-			// retrieve the last block
-			lastBlockTmp, err := dataCollection.GetLastBlockNumber(timeout)
-			if confirm > 0 {
-				confirm--
-				wg.Done()
-			}
-			if err != nil {
-				log.Println(err)
-				continue
-			}
-			atomic.StoreUint64(&lastBlock, lastBlockTmp)
-		}
-	}()
-	wg.Wait()
+// Handler serves the api endpoints using the data collection client it has been injected with,
+// the requests for blocks newer than the last one known by the head tracker are rejected.
+type Handler struct {
+	client *dataCollection.Client
+	heads  *dataCollection.HeadTracker
+	store  dataCollection.ChunkStore
+	// rpcMethods are the JSON-RPC methods served by the RPCHandler
+	rpcMethods map[string]bool
+	// ConfirmationDepth is the number of blocks mined on top of a block after which it can not change anymore,
+	// by default config.ConfirmationDepth.
+	ConfirmationDepth uint64
 }
 
-// init handle the creation of the cache and the update of the lastBlock
-func init() {
-	debug.SetGCPercent(10)
-	UpdateRoutine(config.CacheUpdateLastBlockTime, config.DefaultRequestsTimeout)
+// NewHandler returns the Handler that retrieves the data through the given client
+// and the last block from the given head tracker, that is started and stopped by the caller,
+// the confirmed chunks of the range queries are kept in the store.
+func NewHandler(client *dataCollection.Client, heads *dataCollection.HeadTracker, store dataCollection.ChunkStore) *Handler {
+	h := &Handler{client: client, heads: heads, store: store, ConfirmationDepth: config.ConfirmationDepth}
+	h.AllowRPCMethods(strings.Split(config.RPCAllowedMethods, ",")...)
+	return h
 }
 
 // GetBlockHandler is the handler that manage the caching and execution of the GetBlock function that will contact
-// the third party api in case its not able to satisfy a legit request.
-func GetBlockHandler(w http.ResponseWriter, r *http.Request) {
-	// update in case last update is newer
-	vars := mux.Vars(r)
-	// convert block index string to uint64
-	blockID, _ := strconv.ParseUint(vars["blockId"], 10, 64)
-
-	tmp := atomic.LoadUint64(&lastBlock)
-	if blockID > tmp {
-		w.WriteHeader(http.StatusBadRequest)
-		err := fmt.Errorf("requested id %d latest %d", blockID, lastBlock)
-		log.Println(err.Error())
-		_, _ = w.Write([]byte(err.Error()))
+// the third party api in case its not able to satisfy a legit request, the query params ?transactions=full
+// and ?view=header select the full transactions objects or the header alone.
+func (h *Handler) GetBlockHandler(w http.ResponseWriter, r *http.Request) {
+	view, err := parseBlockView(r.URL.Query())
+	if err != nil {
+		writeError(err, http.StatusBadRequest, w)
 		return
 	}
-	// retuning anything in the body regardless of any error code
-	// it may contain
-	_, _, body, _ := dataCollection.GetBlock(blockID, config.DefaultRequestsTimeout)
-	writeResponse(body, &w)
+	// the upstream request is abandoned as soon as the client goes away
+	ctx, cancel := context.WithTimeout(r.Context(), config.DefaultRequestsTimeout)
+	defer cancel()
+	blockID, tag, ok := h.blockID(ctx, mux.Vars(r)["blockId"], w)
+	if !ok {
+		return
+	}
+	var block *dataCollection.Block
+	if tag == dataCollection.TagPending {
+		block, err = h.client.GetBlockByTag(ctx, tag, view == viewFull)
+	} else {
+		block, err = h.getBlock(ctx, blockID, view == viewFull)
+	}
+	h.writeBlockResult(viewOf(block, view), block != nil, err, blockID, tag, w)
 }
 
 // GetTransactionHandler is the handler that manage the caching and execution of the  GetTransaction function that will contact
-//// the third party api in case its not able to satisfy a legit request.
-func GetTransactionHandler(w http.ResponseWriter, r *http.Request) {
+// the third party api in case its not able to satisfy a legit request.
+func (h *Handler) GetTransactionHandler(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	// retrieve the parameters
-	param := make(map[string]uint64)
-	for _, key := range []string{"blockId", "txId"} {
-		param[key], _ = strconv.ParseUint(vars["blockId"], 10, 64)
+	txID, _ := strconv.ParseUint(vars["txId"], 10, 64)
+
+	ctx, cancel := context.WithTimeout(r.Context(), config.DefaultRequestsTimeout)
+	defer cancel()
+	blockID, tag, ok := h.blockID(ctx, vars["blockId"], w)
+	if !ok {
+		return
 	}
+	var tx *dataCollection.Transaction
+	var err error
+	if tag == dataCollection.TagPending {
+		tx, err = h.client.GetTransactionByTag(ctx, tag, txID)
+	} else {
+		tx, err = h.client.GetTransaction(ctx, blockID, txID)
+	}
+	h.writeBlockResult(tx, tx != nil, err, blockID, tag, w)
+}
 
-	tmp := atomic.LoadUint64(&lastBlock)
-	if param["blockId"] > tmp {
-		w.WriteHeader(http.StatusBadRequest)
-		err := fmt.Errorf("requested id %d latest %d", param["blockId"], lastBlock)
-		log.Println(err.Error())
-		_, _ = w.Write([]byte(err.Error()))
+// GetBlockReceiptsHandler is the handler that retrieves the receipts of all the transactions of a block,
+// cached as the block itself.
+func (h *Handler) GetBlockReceiptsHandler(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), config.DefaultRequestsTimeout)
+	defer cancel()
+	blockID, tag, ok := h.blockID(ctx, mux.Vars(r)["blockId"], w)
+	if !ok {
+		return
+	}
+	if tag == dataCollection.TagPending {
+		writeError(errors.New("the transactions of the pending block have no receipts"), http.StatusBadRequest, w)
+		return
+	}
+	receipts, err := h.client.GetBlockReceipts(ctx, blockID)
+	h.writeBlockResult(receipts, receipts != nil, err, blockID, tag, w)
+}
+
+// blockID parses the blockId param, a block number not newer than the last block known or a tag resolved
+// by the head tracker, in which case the header X-Block-Number reports the number it resolved to.
+// It writes the error when the param is not valid.
+func (h *Handler) blockID(ctx context.Context, value string, w http.ResponseWriter) (blockID uint64, tag string, ok bool) {
+	if blockID, err := strconv.ParseUint(value, 10, 64); err == nil {
+		return blockID, "", h.mined(blockID, w)
+	}
+	blockID, err := h.heads.Resolve(ctx, value)
+	if err != nil {
+		writeError(err, errorStatus(err), w)
+		return 0, "", false
+	}
+	w.Header().Set(cache.BlockNumberHeader, strconv.FormatUint(blockID, 10))
+	return blockID, value, true
+}
+
+// writeBlockResult writes the result read from the block as writeResult, reporting the block by the header X-Block-Number
+// so that the cache evicts it when the block is reorged. The result is cached as long as the block can not change,
+// briefly when it was requested by tag as the block the tag refers to moves with the chain,
+// and never when it was not found as the block may not have been mined yet.
+func (h *Handler) writeBlockResult(result interface{}, found bool, err error, blockID uint64, tag string, w http.ResponseWriter) {
+	if err == nil {
+		w.Header().Set(cache.BlockNumberHeader, strconv.FormatUint(blockID, 10))
+		switch {
+		case !found:
+			setCacheTTL(w, 0)
+		case tag != "":
+			setCacheTTL(w, tagTTL(tag))
+		default:
+			blockNumber := dataCollection.Uint64(blockID)
+			setCacheTTL(w, h.confirmedTTL(&blockNumber))
+		}
+	}
+	writeResult(result, err, w)
+}
+
+// mined reports if the block is not newer than the last block known, writing the error when it is.
+func (h *Handler) mined(blockID uint64, w http.ResponseWriter) bool {
+	if latest := h.heads.Latest(); blockID > latest {
+		writeError(fmt.Errorf("requested id %d latest %d", blockID, latest), http.StatusBadRequest, w)
+		return false
+	}
+	return true
+}
+
+// GetTransactionByHashHandler is the handler that retrieves a transaction by its hash, the transactions mined
+// at least ConfirmationDepth blocks ago are cached as immutable while the pending ones are not cached at all.
+func (h *Handler) GetTransactionByHashHandler(w http.ResponseWriter, r *http.Request) {
+	hash := mux.Vars(r)["txHash"]
+	if !isHash(hash) {
+		writeError(fmt.Errorf("invalid transaction hash %q", hash), http.StatusBadRequest, w)
+		return
+	}
+	ctx, cancel := context.WithTimeout(r.Context(), config.DefaultRequestsTimeout)
+	defer cancel()
+	tx, err := h.client.GetTransactionByHash(ctx, hash)
+	if err == nil && tx == nil {
+		writeError(fmt.Errorf("transaction %s not found", hash), http.StatusNotFound, w)
+		return
+	}
+	if err == nil {
+		setCacheTTL(w, h.confirmedTTL(tx.BlockNumber))
+		setCacheBlock(w, tx.BlockNumber)
+	}
+	writeResult(tx, err, w)
+}
+
+// GetTransactionReceiptHandler is the handler that retrieves the receipt of a transaction by its hash,
+// cached as the transaction itself, the transactions unknown or not mined yet have no receipt.
+func (h *Handler) GetTransactionReceiptHandler(w http.ResponseWriter, r *http.Request) {
+	hash := mux.Vars(r)["txHash"]
+	if !isHash(hash) {
+		writeError(fmt.Errorf("invalid transaction hash %q", hash), http.StatusBadRequest, w)
+		return
+	}
+	ctx, cancel := context.WithTimeout(r.Context(), config.DefaultRequestsTimeout)
+	defer cancel()
+	receipt, err := h.client.GetTransactionReceipt(ctx, hash)
+	if err == nil && receipt == nil {
+		writeError(fmt.Errorf("receipt of the transaction %s not found", hash), http.StatusNotFound, w)
+		return
+	}
+	if err == nil {
+		setCacheTTL(w, h.confirmedTTL(&receipt.BlockNumber))
+		setCacheBlock(w, &receipt.BlockNumber)
+	}
+	writeResult(receipt, err, w)
+}
+
+// confirmedTTL returns how long the data included in the given block can be cached: indefinitely once the block
+// can not change anymore, for about a block while it is near the head and never when it has not been mined yet.
+func (h *Handler) confirmedTTL(blockNumber *dataCollection.Uint64) time.Duration {
+	switch {
+	case blockNumber == nil:
+		return 0
+	case uint64(*blockNumber) <= h.confirmed():
+		return config.CacheImmutableTime
+	default:
+		return config.CacheUnconfirmedTime
+	}
+}
+
+// confirmed returns the last block that can not change anymore, the one ConfirmationDepth blocks before the last block
+// or the last finalized block known when it is newer.
+func (h *Handler) confirmed() uint64 {
+	confirmed := h.heads.Finalized()
+	if latest := h.heads.Latest(); latest >= h.ConfirmationDepth && latest-h.ConfirmationDepth > confirmed {
+		confirmed = latest - h.ConfirmationDepth
+	}
+	return confirmed
+}
+
+// setCacheTTL sets the Cache-Control header that tells the cache, and the clients, how long the response is valid.
+func setCacheTTL(w http.ResponseWriter, ttl time.Duration) {
+	if ttl <= 0 {
+		w.Header().Set("Cache-Control", "no-store")
+		return
+	}
+	if ttl >= config.CacheImmutableTime {
+		// the clients do not need to revalidate the responses that can not change anymore
+		w.Header().Set("Cache-Control", fmt.Sprintf("public, max-age=%d, immutable", ttl/time.Second))
+		return
+	}
+	w.Header().Set("Cache-Control", fmt.Sprintf("public, max-age=%d", ttl/time.Second))
+}
+
+// setCacheBlock sets the header X-Block-Number reporting the block the response was read from,
+// so that the cache evicts it when the block is reorged, nothing for the data not mined yet.
+func setCacheBlock(w http.ResponseWriter, blockNumber *dataCollection.Uint64) {
+	if blockNumber != nil {
+		w.Header().Set(cache.BlockNumberHeader, strconv.FormatUint(uint64(*blockNumber), 10))
+	}
+}
+
+// status is the body returned by the StatusHandler.
+type status struct {
+	LastBlock  uint64                          `json:"lastBlock"`
+	Upstreams  []dataCollection.UpstreamHealth `json:"upstreams"`
+	Coalescing dataCollection.CoalescingStats  `json:"coalescing"`
+	Reorgs     uint64                          `json:"reorgs"`
+}
+
+// StatusHandler reports the last block known and the health of each upstream,
+// so that operators can see why the traffic moved between them, how many upstream calls were saved by coalescing
+// and how many reorgs were detected.
+func (h *Handler) StatusHandler(w http.ResponseWriter, r *http.Request) {
+	body, _ := json.Marshal(status{
+		LastBlock:  h.heads.Latest(),
+		Upstreams:  h.client.Health(),
+		Coalescing: h.client.Coalescing(),
+		Reorgs:     h.heads.Reorgs(),
+	})
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write(body)
+}
+
+// writeResult re-encodes the result in the JSON-RPC envelope of the upstream responses,
+// if the upstream was not able to provide it, it writes the error with the matching status instead.
+func writeResult(result interface{}, err error, w http.ResponseWriter) {
+	var raw []byte
+	if err == nil {
+		raw, err = json.Marshal(result)
+	}
+	if err != nil {
+		writeError(err, errorStatus(err), w)
 		return
 	}
-	// retuning anything in the body regardless of any error code
-	// it may contain
-	_, _, body, _ := dataCollection.GetTransaction(param["blockId"], param["txId"], config.DefaultRequestsTimeout)
+	body, _ := json.Marshal(dataCollection.Response{JSONRPC: "2.0", ID: json.RawMessage("1"), Result: raw})
 	writeResponse(body, &w)
 }
 
+// errorBody is the body written by the handlers for every request they are not able to satisfy.
+type errorBody struct {
+	Status  int    `json:"status"`
+	Message string `json:"message"`
+}
+
+// errorStatus translates the errors returned by the upstream into the http status to answer with.
+func errorStatus(err error) int {
+	switch {
+	case errors.Is(err, dataCollection.ErrRateLimited):
+		return http.StatusTooManyRequests
+	case errors.Is(err, dataCollection.ErrInvalidParams):
+		return http.StatusBadRequest
+	case errors.Is(err, dataCollection.ErrNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, dataCollection.ErrTimeout):
+		return http.StatusGatewayTimeout
+	case errors.Is(err, dataCollection.ErrCircuitOpen):
+		return http.StatusServiceUnavailable
+	default:
+		return http.StatusBadGateway
+	}
+}
+
+// writeError logs the error and writes it with the given status.
+func writeError(err error, statusCode int, w http.ResponseWriter) {
+	log.Println(err.Error())
+	body, _ := json.Marshal(errorBody{Status: statusCode, Message: err.Error()})
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	_, _ = w.Write(body)
+}
+
 // writeResponse writes the response.
 func writeResponse(body []byte, w *http.ResponseWriter) {
 	(*w).Header().Set("Content-Type", "text/plain; charset=utf-8")
@@ -2,23 +2,124 @@ package API
 
 import (
 	"bytes"
+	"context"
+	"encoding/json"
 	"fmt"
+	"github.com/LucaPaterlini/infura/dataCollection"
 	"github.com/go-test/deep"
 	"github.com/gorilla/mux"
+	"io"
 	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 )
 
+// fakeUpstream is the in memory Upstream that replays upstreamReplies after the given delay.
+type fakeUpstream struct {
+	delay time.Duration
+}
+
+func (f fakeUpstream) Post(ctx context.Context, jsonStr []byte) (int, map[string][]string, []byte, error) {
+	select {
+	case <-ctx.Done():
+		return 0, nil, nil, ctx.Err()
+	case <-time.After(f.delay):
+	}
+	header := map[string][]string{"Content-Type": {"application/json"}}
+	// the batches are answered with the replies of each request
+	if bytes.HasPrefix(jsonStr, []byte("[")) {
+		var batch []json.RawMessage
+		if err := json.Unmarshal(jsonStr, &batch); err != nil {
+			return 0, nil, nil, err
+		}
+		replies := make([][]byte, len(batch))
+		for i, req := range batch {
+			reply, err := replay(req)
+			if err != nil {
+				return 0, nil, nil, err
+			}
+			replies[i] = reply
+		}
+		return http.StatusOK, header, append(append([]byte("["), bytes.Join(replies, []byte(","))...), ']'), nil
+	}
+	reply, err := replay(jsonStr)
+	return http.StatusOK, header, reply, err
+}
+
+// replay returns the reply of upstreamReplies to the request, with the id of the request.
+func replay(jsonStr []byte) ([]byte, error) {
+	var req struct {
+		Method string
+		Params json.RawMessage
+		ID     json.RawMessage
+	}
+	if err := json.Unmarshal(jsonStr, &req); err != nil {
+		return nil, err
+	}
+	params, _ := json.Marshal(req.Params)
+	if reply, ok := upstreamReplies[req.Method+string(params)]; ok {
+		return bytes.Replace([]byte(reply), []byte(`"id":1,`), []byte(`"id":`+string(req.ID)+`,`), 1), nil
+	}
+	return []byte(fmt.Sprintf(`{"jsonrpc":"2.0","id":%s,"result":null}`, req.ID)), nil
+}
+
+// mapStore is the ChunkStore that keeps the values in a map.
+type mapStore struct {
+	mtx    sync.Mutex
+	values map[string][]byte
+}
+
+func (s *mapStore) Get(key string) ([]byte, bool) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	value, ok := s.values[key]
+	return value, ok
+}
+
+func (s *mapStore) Set(key string, value []byte, expiration time.Time) {
+	s.mtx.Lock()
+	s.values[key] = value
+	s.mtx.Unlock()
+}
+
+func (s *mapStore) Release(key string) {
+	s.mtx.Lock()
+	delete(s.values, key)
+	s.mtx.Unlock()
+}
+
+// newTestHeadTracker returns the started HeadTracker that polls the last block from the fake upstream.
+func newTestHeadTracker(t *testing.T, client *dataCollection.Client) *dataCollection.HeadTracker {
+	heads := dataCollection.NewHeadTracker(client, "")
+	if err := heads.Start(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	return heads
+}
+
+// newTestHandler returns a Handler connected to the fake upstream with the last block already retrieved,
+// the returned HeadTracker has to be stopped by the caller.
+func newTestHandler(t *testing.T) (*Handler, *dataCollection.HeadTracker) {
+	client := dataCollection.NewClient(fakeUpstream{delay: 10 * time.Millisecond})
+	heads := newTestHeadTracker(t, client)
+	return NewHandler(client, heads, nil), heads
+}
+
 func testHandler(t *testing.T, f func(http.ResponseWriter, *http.Request), testCases []handlerTest) {
 	for _, tc := range testCases {
 		description := fmt.Sprintf("Test:%s, request=%v", tc.description, tc.requestPath)
 		// initialization set the last block
 		var err error
 
-		req, err := http.NewRequest("GET", tc.requestPath, nil)
+		method, body := http.MethodGet, io.Reader(nil)
+		if tc.requestBody != "" {
+			method, body = http.MethodPost, strings.NewReader(tc.requestBody)
+		}
+		req, err := http.NewRequest(method, tc.requestPath, body)
 		if err != nil {
 			t.Error(description + "\nrequest initialization error")
 		}
@@ -45,19 +146,160 @@ func testHandler(t *testing.T, f func(http.ResponseWriter, *http.Request), testC
 	}
 }
 
-func TestUpdateRoutine(t *testing.T) {
-	// test the execution, it does not have any check because
-	// the inner function its already tested ,
-	// the main purpose is to be sure its not giving any panic.
-	UpdateRoutine(time.Second, time.Nanosecond)
+func TestGetBlockHandler(t *testing.T) {
+	h, heads := newTestHandler(t)
+	defer heads.Stop()
+	testHandler(t, h.GetBlockHandler, testCasesGetBlockHandler)
 }
 
-func TestGetBlockHandler(t *testing.T) {
-	testHandler(t, GetBlockHandler, testCasesGetBlockHandler)
-	// wait to allow the go routine that do the GetBlockHandler to be executed inside the ticker loop
+func TestGetTransactionHandler(t *testing.T) {
+	h, heads := newTestHandler(t)
+	defer heads.Stop()
+	testHandler(t, h.GetTransactionHandler, testCasesGetTransaction)
+}
 
+func TestGetTransactionByHashHandler(t *testing.T) {
+	h, heads := newTestHandler(t)
+	defer heads.Stop()
+	testHandler(t, h.GetTransactionByHashHandler, testCasesGetTransactionByHash)
 }
 
-func TestGetTransactionHandler(t *testing.T) {
-	testHandler(t, GetTransactionHandler, testCasesGetTransaction)
+func TestGetTransactionReceiptHandler(t *testing.T) {
+	h, heads := newTestHandler(t)
+	defer heads.Stop()
+	testHandler(t, h.GetTransactionReceiptHandler, testCasesGetTransactionReceipt)
+}
+
+func TestGetBlockReceiptsHandler(t *testing.T) {
+	h, heads := newTestHandler(t)
+	defer heads.Stop()
+	testHandler(t, h.GetBlockReceiptsHandler, testCasesGetBlockReceipts)
+}
+
+// fakeHealthUpstream is the fakeUpstream able to report the health of its endpoint.
+type fakeHealthUpstream struct {
+	fakeUpstream
+}
+
+func (f fakeHealthUpstream) Health() []dataCollection.UpstreamHealth {
+	return []dataCollection.UpstreamHealth{{URL: "http://fake", Healthy: true, LastBlock: 0x7fc4a9}}
+}
+
+func TestStatusHandler(t *testing.T) {
+	client := dataCollection.NewClient(fakeHealthUpstream{})
+	heads := newTestHeadTracker(t, client)
+	defer heads.Stop()
+	h := NewHandler(client, heads, nil)
+
+	gotW := httptest.NewRecorder()
+	h.StatusHandler(gotW, httptest.NewRequest(http.MethodGet, "/v1/status", nil))
+
+	expected := `{"lastBlock":8373417,"upstreams":[{"url":"http://fake","healthy":true,"lastBlock":8373417,"lastCheck":"0001-01-01T00:00:00Z"}],"coalescing":{"upstreamCalls":1,"savedCalls":0},"reorgs":0}`
+	if gotW.Body.String() != expected {
+		t.Errorf("Expected: %s\nGot     : %s", expected, gotW.Body.String())
+	}
+}
+
+// failingUpstream is the Upstream that answers every request with the given body.
+type failingUpstream struct {
+	statusCode int
+	body       string
+	err        error
+}
+
+func (f failingUpstream) Post(ctx context.Context, jsonStr []byte) (int, map[string][]string, []byte, error) {
+	return f.statusCode, nil, []byte(f.body), f.err
+}
+
+func TestGetBlockHandler_upstreamErrors(t *testing.T) {
+	heads := newTestHeadTracker(t, dataCollection.NewClient(fakeUpstream{}))
+	defer heads.Stop()
+	for _, tc := range []struct {
+		upstream       failingUpstream
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			upstream:       failingUpstream{statusCode: http.StatusOK, body: `{"jsonrpc":"2.0","id":1,"error":{"code":-32005,"message":"daily request count exceeded"}}`},
+			expectedStatus: http.StatusTooManyRequests,
+			expectedBody:   `{"status":429,"message":"upstream rate limit exceeded: daily request count exceeded (code -32005)"}`,
+		},
+		{
+			upstream:       failingUpstream{statusCode: http.StatusOK, body: `{"jsonrpc":"2.0","id":1,"error":{"code":-32602,"message":"invalid argument 0"}}`},
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `{"status":400,"message":"invalid params: invalid argument 0 (code -32602)"}`,
+		},
+		{
+			upstream:       failingUpstream{statusCode: http.StatusOK, body: `{"jsonrpc":"2.0","id":1,"error":{"code":-32001,"message":"block not found"}}`},
+			expectedStatus: http.StatusNotFound,
+			expectedBody:   `{"status":404,"message":"resource not found: block not found (code -32001)"}`,
+		},
+		{
+			upstream:       failingUpstream{statusCode: http.StatusServiceUnavailable, body: `<html></html>`},
+			expectedStatus: http.StatusBadGateway,
+			expectedBody:   `{"status":502,"message":"upstream internal error: status code 503"}`,
+		},
+		{
+			upstream:       failingUpstream{err: timeoutError{}},
+			expectedStatus: http.StatusGatewayTimeout,
+			expectedBody:   `{"status":504,"message":"upstream timeout: i/o timeout"}`,
+		},
+		{
+			upstream:       failingUpstream{err: dataCollection.ErrCircuitOpen},
+			expectedStatus: http.StatusServiceUnavailable,
+			expectedBody:   `{"status":503,"message":"upstream circuit breaker open"}`,
+		},
+	} {
+		h := NewHandler(dataCollection.NewClient(tc.upstream), heads, nil)
+
+		gotW := httptest.NewRecorder()
+		req := mux.SetURLVars(httptest.NewRequest(http.MethodGet, "/v1/block/12", nil), map[string]string{"blockId": "12"})
+		h.GetBlockHandler(gotW, req)
+		if gotW.Code != tc.expectedStatus || gotW.Body.String() != tc.expectedBody {
+			t.Errorf("Expected: %d %s\nGot     : %d %s", tc.expectedStatus, tc.expectedBody, gotW.Code, gotW.Body.String())
+		}
+	}
+}
+
+// timeoutError is the net.Error returned when the upstream does not answer in time.
+type timeoutError struct{}
+
+func (timeoutError) Error() string   { return "i/o timeout" }
+func (timeoutError) Timeout() bool   { return true }
+func (timeoutError) Temporary() bool { return true }
+
+func TestHandler_confirmationDepth(t *testing.T) {
+	h, heads := newTestHandler(t)
+	defer heads.Stop()
+	path := "/v1/tx/hash/0xcdcdcdcdcdcdcdcdcdcdcdcdcdcdcdcdcdcdcdcdcdcdcdcdcdcdcdcdcdcdcdcd"
+	router := mux.NewRouter()
+	router.HandleFunc("/v1/tx/hash/{txHash}", h.GetTransactionByHashHandler)
+	// the transaction mined less than 12 blocks ago is final on a chain without reorgs
+	for depth, expected := range map[uint64]string{12: "public, max-age=12", 0: "public, max-age=31536000, immutable"} {
+		h.ConfirmationDepth = depth
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		if got := w.Header().Get("Cache-Control"); got != expected {
+			t.Errorf("depth %d Expected: %s got : %s", depth, expected, got)
+		}
+	}
+}
+
+func TestHandler_finalized(t *testing.T) {
+	h, heads := newTestHandler(t)
+	defer heads.Stop()
+	router := mux.NewRouter()
+	router.HandleFunc("/v1/{blockId:[0-9]+}", h.GetBlockHandler)
+	// no block is deep enough, the block 12 can not change anymore only once it is known to be finalized
+	h.ConfirmationDepth = 1 << 62
+	for _, expected := range []string{"public, max-age=12", "public, max-age=31536000, immutable"} {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/v1/12", nil))
+		if got := w.Header().Get("Cache-Control"); got != expected {
+			t.Errorf("Expected: %s got : %s", expected, got)
+		}
+		if _, err := heads.Resolve(context.Background(), dataCollection.TagFinalized); err != nil {
+			t.Fatal(err)
+		}
+	}
 }
@@ -0,0 +1,93 @@
+package API
+
+import (
+	"context"
+	"fmt"
+	"github.com/LucaPaterlini/infura/config"
+	"github.com/LucaPaterlini/infura/dataCollection"
+	"github.com/LucaPaterlini/infura/middlewares/cache"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// GetLogsHandler is the handler that retrieves the logs emitted in the range of blocks fromBlock-toBlock,
+// by default the last block, optionally only by the comma separated addresses and with the comma separated
+// alternatives of topic0 to topic3. The range is split in chunks and the confirmed ones are cached,
+// so that the historical queries are requested to the third party api only once.
+func (h *Handler) GetLogsHandler(w http.ResponseWriter, r *http.Request) {
+	filter, err := h.logFilter(r.URL.Query())
+	if err != nil {
+		writeError(err, http.StatusBadRequest, w)
+		return
+	}
+	ctx, cancel := context.WithTimeout(r.Context(), config.LogsRequestsTimeout)
+	defer cancel()
+	logs, err := h.client.GetLogsRange(ctx, filter, h.confirmed(), h.store)
+	if err == nil {
+		setCacheTTL(w, h.confirmedTTL(&filter.ToBlock))
+		// the cache evicts the logs when any block of the range is reorged
+		w.Header().Set(cache.BlockRangeHeader, fmt.Sprintf("%d-%d", filter.FromBlock, filter.ToBlock))
+	}
+	writeResult(logs, err, w)
+}
+
+// logFilter parses and validates the query params of the GetLogsHandler.
+func (h *Handler) logFilter(query url.Values) (filter dataCollection.LogFilter, err error) {
+	latest := h.heads.Latest()
+	to, from := latest, latest
+	if value := query.Get("toBlock"); value != "" {
+		if to, err = strconv.ParseUint(value, 10, 64); err != nil {
+			return filter, fmt.Errorf("invalid toBlock %q", value)
+		}
+		from = to
+	}
+	if value := query.Get("fromBlock"); value != "" {
+		if from, err = strconv.ParseUint(value, 10, 64); err != nil {
+			return filter, fmt.Errorf("invalid fromBlock %q", value)
+		}
+	}
+	switch {
+	case to > latest:
+		return filter, fmt.Errorf("requested id %d latest %d", to, latest)
+	case from > to:
+		return filter, fmt.Errorf("fromBlock %d after toBlock %d", from, to)
+	case to-from >= config.LogsMaxRange:
+		return filter, fmt.Errorf("range of %d blocks larger than %d", to-from+1, config.LogsMaxRange)
+	}
+	filter.FromBlock, filter.ToBlock = dataCollection.Uint64(from), dataCollection.Uint64(to)
+
+	for _, address := range splitList(query["address"]) {
+		if err = checkAddress(address); err != nil {
+			return filter, err
+		}
+		filter.Addresses = append(filter.Addresses, address)
+	}
+	for i := 0; i < 4; i++ {
+		topics := splitList(query["topic"+strconv.Itoa(i)])
+		for _, topic := range topics {
+			if !isHash(topic) {
+				return filter, fmt.Errorf("invalid topic%d %q", i, topic)
+			}
+		}
+		filter.Topics = append(filter.Topics, topics)
+	}
+	// the positions after the last topic accept any topic
+	for len(filter.Topics) > 0 && filter.Topics[len(filter.Topics)-1] == nil {
+		filter.Topics = filter.Topics[:len(filter.Topics)-1]
+	}
+	return filter, nil
+}
+
+// splitList returns the values of a query param given either repeated or comma separated.
+func splitList(values []string) (list []string) {
+	for _, value := range values {
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				list = append(list, item)
+			}
+		}
+	}
+	return
+}
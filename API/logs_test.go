@@ -0,0 +1,19 @@
+package API
+
+import (
+	"github.com/go-test/deep"
+	"testing"
+)
+
+func TestGetLogsHandler(t *testing.T) {
+	h, heads := newTestHandler(t)
+	defer heads.Stop()
+	testHandler(t, h.GetLogsHandler, testCasesGetLogs)
+}
+
+func TestSplitList(t *testing.T) {
+	got := splitList([]string{"0x01,0x02", " 0x03 ", ""})
+	if diff := deep.Equal([]string{"0x01", "0x02", "0x03"}, got); diff != nil {
+		t.Error(diff)
+	}
+}
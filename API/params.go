@@ -0,0 +1,132 @@
+package API
+
+import (
+	"encoding/hex"
+	"fmt"
+	"github.com/LucaPaterlini/infura/config"
+	"github.com/LucaPaterlini/infura/dataCollection"
+	"golang.org/x/crypto/sha3"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// BlockIDPattern is the pattern of the routes param blockId, a block number or a tag.
+const BlockIDPattern = "[0-9]+|latest|earliest|pending|safe|finalized"
+
+// isHash reports if s is a 0x prefixed 32 bytes hex string.
+func isHash(s string) bool {
+	if len(s) != 66 || !strings.HasPrefix(s, "0x") {
+		return false
+	}
+	_, err := hex.DecodeString(s[2:])
+	return err == nil
+}
+
+// isSlot reports if s is a 0x prefixed hex string of up to 32 bytes, as the storage slots.
+func isSlot(s string) bool {
+	if len(s) < 3 || len(s) > 66 || !strings.HasPrefix(s, "0x") {
+		return false
+	}
+	digits := s[2:]
+	if len(digits)%2 == 1 {
+		digits = "0" + digits
+	}
+	_, err := hex.DecodeString(digits)
+	return err == nil
+}
+
+// isData reports if s is a 0x prefixed hex string of whole bytes, as the input of a call.
+func isData(s string) bool {
+	if !strings.HasPrefix(s, "0x") {
+		return false
+	}
+	_, err := hex.DecodeString(s[2:])
+	return err == nil
+}
+
+// checkAddress verifies that s is a 0x prefixed 20 bytes hex string, with the EIP-55 checksum when it is mixed case.
+func checkAddress(s string) error {
+	if len(s) != 42 || !strings.HasPrefix(s, "0x") {
+		return fmt.Errorf("invalid address %q", s)
+	}
+	if _, err := hex.DecodeString(s[2:]); err != nil {
+		return fmt.Errorf("invalid address %q", s)
+	}
+	digits := s[2:]
+	if digits == strings.ToLower(digits) || digits == strings.ToUpper(digits) {
+		return nil
+	}
+	if checksummed := checksumAddress(s); s != checksummed {
+		return fmt.Errorf("invalid address checksum %q, expected %q", s, checksummed)
+	}
+	return nil
+}
+
+// checksumAddress returns the EIP-55 mixed case encoding of the address.
+func checksumAddress(s string) string {
+	digits := []byte(strings.ToLower(s[2:]))
+	h := sha3.NewLegacyKeccak256()
+	_, _ = h.Write(digits)
+	hash := h.Sum(nil)
+	for i, c := range digits {
+		// each letter is uppercase when the matching nibble of the hash is at least 8
+		nibble := hash[i/2] >> 4
+		if i%2 == 1 {
+			nibble = hash[i/2] & 0x0f
+		}
+		if c >= 'a' && nibble >= 8 {
+			digits[i] = c - 'a' + 'A'
+		}
+	}
+	return "0x" + string(digits)
+}
+
+// blockRef parses the block a state query refers to, given as a number not newer than latest,
+// as a block hash or as a tag, by default the latest block.
+func blockRef(value string, latest uint64) (dataCollection.BlockRef, error) {
+	switch value {
+	case "":
+		return dataCollection.BlockRef{Tag: dataCollection.TagLatest}, nil
+	case dataCollection.TagLatest, dataCollection.TagEarliest, dataCollection.TagPending, dataCollection.TagSafe, dataCollection.TagFinalized:
+		return dataCollection.BlockRef{Tag: value}, nil
+	}
+	if isHash(value) {
+		return dataCollection.BlockRef{Hash: value}, nil
+	}
+	blockNumber, err := strconv.ParseUint(value, 10, 64)
+	if err != nil {
+		return dataCollection.BlockRef{}, fmt.Errorf("invalid block %q", value)
+	}
+	if blockNumber > latest {
+		return dataCollection.BlockRef{}, fmt.Errorf("requested id %d latest %d", blockNumber, latest)
+	}
+	return dataCollection.BlockNumberRef(blockNumber), nil
+}
+
+// blockRefTTL returns how long the state read at the block can be cached, permanently when the block can not change anymore.
+func (h *Handler) blockRefTTL(block dataCollection.BlockRef) time.Duration {
+	switch {
+	case block.Number != nil:
+		blockNumber := dataCollection.Uint64(*block.Number)
+		return h.confirmedTTL(&blockNumber)
+	case block.Hash != "":
+		return config.CacheImmutableTime
+	default:
+		return tagTTL(block.Tag)
+	}
+}
+
+// tagTTL returns how long the data at the block the tag refers to can be cached.
+func tagTTL(tag string) time.Duration {
+	switch tag {
+	case dataCollection.TagEarliest:
+		return config.CacheImmutableTime
+	case dataCollection.TagPending:
+		return 0
+	case dataCollection.TagLatest, "":
+		return config.CacheLatestTime
+	default:
+		return config.CacheUnconfirmedTime
+	}
+}
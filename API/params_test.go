@@ -0,0 +1,79 @@
+package API
+
+import (
+	"github.com/LucaPaterlini/infura/dataCollection"
+	"github.com/go-test/deep"
+	"testing"
+)
+
+func TestCheckAddress(t *testing.T) {
+	for _, tc := range []struct {
+		address       string
+		expectedError string
+	}{
+		// the test vectors of EIP-55
+		{address: "0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed"},
+		{address: "0xfB6916095ca1df60bB79Ce92cE3Ea74c37c5d359"},
+		{address: "0xdbF03B407c01E7cD3CBea99509d93f8DDDC8C6FB"},
+		{address: "0xD1220A0cf47c7B9Be7A2E6BA89F429762e7b9aDb"},
+		// the addresses without checksum
+		{address: "0x5aaeb6053f3e94c9b9a09f33669435e7ef1beaed"},
+		{address: "0x5AAEB6053F3E94C9B9A09F33669435E7EF1BEAED"},
+		{address: "0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAeD", expectedError: `invalid address checksum "0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAeD", expected "0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed"`},
+		{address: "0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeA", expectedError: `invalid address "0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeA"`},
+		{address: "5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed00", expectedError: `invalid address "5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed00"`},
+		{address: "0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAeZ", expectedError: `invalid address "0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAeZ"`},
+	} {
+		err := checkAddress(tc.address)
+		if got := ""; err != nil {
+			got = err.Error()
+			if got != tc.expectedError {
+				t.Errorf("Expected: %s got : %s", tc.expectedError, got)
+			}
+		} else if tc.expectedError != "" {
+			t.Errorf("Expected: %s got : nil", tc.expectedError)
+		}
+	}
+}
+
+func TestIsSlot(t *testing.T) {
+	for slot, expected := range map[string]bool{
+		"0x0":  true,
+		"0x02": true,
+		"0x290decd9548b62a8d60345a988386fc84ba6bc95484008f6362f93160ef3e563":  true,
+		"0x290decd9548b62a8d60345a988386fc84ba6bc95484008f6362f93160ef3e5630": false,
+		"0x":   false,
+		"2":    false,
+		"0xzz": false,
+	} {
+		if got := isSlot(slot); got != expected {
+			t.Errorf("isSlot(%q) Expected: %t got : %t", slot, expected, got)
+		}
+	}
+}
+
+func TestBlockRef(t *testing.T) {
+	for _, tc := range []struct {
+		value         string
+		expected      dataCollection.BlockRef
+		expectedError string
+	}{
+		{value: "", expected: dataCollection.BlockRef{Tag: "latest"}},
+		{value: "finalized", expected: dataCollection.BlockRef{Tag: "finalized"}},
+		{value: "12", expected: dataCollection.BlockNumberRef(12)},
+		{value: "0x7277db362335d9cdbcf1ae9af8c6a4d0d2ef62be7cdc1c4b8fcb7f4a1f2c5a4e", expected: dataCollection.BlockRef{Hash: "0x7277db362335d9cdbcf1ae9af8c6a4d0d2ef62be7cdc1c4b8fcb7f4a1f2c5a4e"}},
+		{value: "13", expectedError: "requested id 13 latest 12"},
+		{value: "newest", expectedError: `invalid block "newest"`},
+	} {
+		got, err := blockRef(tc.value, 12)
+		if err != nil {
+			if err.Error() != tc.expectedError {
+				t.Errorf("Expected: %s got : %s", tc.expectedError, err)
+			}
+			continue
+		}
+		if diff := deep.Equal(tc.expected, got); diff != nil {
+			t.Errorf("blockRef(%q) %v", tc.value, diff)
+		}
+	}
+}
@@ -0,0 +1,292 @@
+package API
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/LucaPaterlini/infura/config"
+	"github.com/LucaPaterlini/infura/dataCollection"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// The JSON-RPC error codes answered by the RPCHandler, as defined by the JSON-RPC 2.0 specification and EIP-1474.
+const (
+	codeParseError     = -32700
+	codeInvalidRequest = -32600
+	codeMethodNotFound = -32601
+	codeInvalidParams  = -32602
+	codeInternalError  = -32603
+	codeLimitExceeded  = -32005
+)
+
+// rpcBlockParams maps the deterministic methods to the position of the param selecting the block they read,
+// their results are cached when the block is selected by hash or by a confirmed number,
+// -1 marks the methods whose result never changes.
+var rpcBlockParams = map[string]int{
+	"eth_chainId":                             -1,
+	"net_version":                             -1,
+	"eth_getBlockByNumber":                    0,
+	"eth_getBlockByHash":                      0,
+	"eth_getBlockTransactionCountByNumber":    0,
+	"eth_getBlockTransactionCountByHash":      0,
+	"eth_getTransactionByBlockNumberAndIndex": 0,
+	"eth_getTransactionByBlockHashAndIndex":   0,
+	"eth_getBlockReceipts":                    0,
+	"eth_getBalance":                          1,
+	"eth_getTransactionCount":                 1,
+	"eth_getCode":                             1,
+	"eth_call":                                1,
+	"eth_getStorageAt":                        2,
+}
+
+// rpcRequest is a request of the body of the RPCHandler, the requests without id are notifications.
+type rpcRequest struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params"`
+}
+
+// rpcCall is a request of the body of the RPCHandler that is answered from the cache or by the upstream.
+type rpcCall struct {
+	index  int
+	method string
+	params []json.RawMessage
+	key    string
+}
+
+// AllowRPCMethods replaces the JSON-RPC methods served by the RPCHandler, by default config.RPCAllowedMethods,
+// it has to be called before serving the requests.
+func (h *Handler) AllowRPCMethods(methods ...string) {
+	h.rpcMethods = make(map[string]bool, len(methods))
+	for _, method := range methods {
+		if method = strings.TrimSpace(method); method != "" {
+			h.rpcMethods[method] = true
+		}
+	}
+}
+
+// RPCHandler is the handler that serves a single JSON-RPC request or a batch of them, so that the service can be used
+// as the RPC url of the JSON-RPC clients. The methods not allowed are refused, the results of the deterministic ones
+// at a block that can not change anymore are cached permanently and the others are forwarded to the third party api,
+// with a single batch request for the whole batch. Each response carries the id of its request.
+func (h *Handler) RPCHandler(w http.ResponseWriter, r *http.Request) {
+	body, err := ioutil.ReadAll(http.MaxBytesReader(w, r.Body, config.RPCMaxBodySize))
+	if err != nil {
+		writeRPC(rpcError(codeInvalidRequest, fmt.Sprintf("invalid request: %s", err)), w)
+		return
+	}
+	body = bytes.TrimSpace(body)
+	if !bytes.HasPrefix(body, []byte("[")) {
+		if response := h.rpcServe(r.Context(), []json.RawMessage{body})[0]; response != nil {
+			writeRPC(response, w)
+			return
+		}
+		// the notifications are not answered
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	var batch []json.RawMessage
+	if err := json.Unmarshal(body, &batch); err != nil {
+		writeRPC(rpcError(codeParseError, fmt.Sprintf("parse error: %s", err)), w)
+		return
+	}
+	switch {
+	case len(batch) == 0:
+		writeRPC(rpcError(codeInvalidRequest, "empty batch"), w)
+		return
+	case len(batch) > config.RPCMaxBatchSize:
+		writeRPC(rpcError(codeInvalidRequest, fmt.Sprintf("batch of %d requests larger than %d", len(batch), config.RPCMaxBatchSize)), w)
+		return
+	}
+	responses := []*dataCollection.Response{}
+	for _, response := range h.rpcServe(r.Context(), batch) {
+		if response != nil {
+			responses = append(responses, response)
+		}
+	}
+	if len(responses) == 0 {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	writeRPC(responses, w)
+}
+
+// rpcServe answers the requests in order with their ids, nil for the valid notifications.
+func (h *Handler) rpcServe(ctx context.Context, raws []json.RawMessage) []*dataCollection.Response {
+	responses := make([]*dataCollection.Response, len(raws))
+	ids := make([]json.RawMessage, len(raws))
+	var calls []rpcCall
+	for i, raw := range raws {
+		ids[i] = json.RawMessage("null")
+		var req rpcRequest
+		if err := json.Unmarshal(raw, &req); err != nil {
+			if json.Valid(raw) {
+				responses[i] = rpcError(codeInvalidRequest, fmt.Sprintf("invalid request: %s", err))
+			} else {
+				responses[i] = rpcError(codeParseError, fmt.Sprintf("parse error: %s", err))
+			}
+			continue
+		}
+		// the valid requests without id are notifications, that are executed but not answered,
+		// the invalid ones are answered with a null id
+		call, errResponse := h.rpcCall(req)
+		if len(req.ID) > 0 || errResponse == nil {
+			ids[i] = req.ID
+		}
+		switch {
+		case errResponse != nil:
+			responses[i] = errResponse
+		case call.key != "" && h.store != nil:
+			if result, ok := h.store.Get(call.key); ok {
+				responses[i] = &dataCollection.Response{Result: result}
+				continue
+			}
+			fallthrough
+		default:
+			call.index = i
+			calls = append(calls, call)
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, config.DefaultRequestsTimeout)
+	defer cancel()
+	h.forwardRPC(ctx, calls, responses)
+	for i, response := range responses {
+		if len(ids[i]) == 0 {
+			responses[i] = nil
+			continue
+		}
+		response.JSONRPC, response.ID = "2.0", ids[i]
+	}
+	return responses
+}
+
+// rpcCall validates the request and returns the call that answers it, or the error response.
+func (h *Handler) rpcCall(req rpcRequest) (call rpcCall, errResponse *dataCollection.Response) {
+	if req.Method == "" {
+		return call, rpcError(codeInvalidRequest, "invalid request: missing method")
+	}
+	if !h.rpcMethods[req.Method] {
+		return call, rpcError(codeMethodNotFound, fmt.Sprintf("the method %s is not available", req.Method))
+	}
+	call.method = req.Method
+	if len(req.Params) > 0 && !bytes.Equal(req.Params, []byte("null")) {
+		if err := json.Unmarshal(req.Params, &call.params); err != nil {
+			return call, rpcError(codeInvalidParams, "invalid params: expected an array")
+		}
+	}
+	if h.rpcCacheable(call.method, call.params) {
+		params, _ := json.Marshal(call.params)
+		call.key = "rpc:" + call.method + string(params)
+	}
+	return call, nil
+}
+
+// rpcCacheable reports if the result of the method can not change anymore, as it is deterministic
+// and it reads a block selected by hash or by a confirmed number.
+func (h *Handler) rpcCacheable(method string, params []json.RawMessage) bool {
+	position, ok := rpcBlockParams[method]
+	switch {
+	case !ok:
+		return false
+	case position < 0:
+		return true
+	case position >= len(params):
+		// the missing block defaults to the latest one
+		return false
+	}
+	var block struct {
+		BlockHash   string `json:"blockHash"`
+		BlockNumber string `json:"blockNumber"`
+	}
+	if err := json.Unmarshal(params[position], &block.BlockNumber); err != nil {
+		if err := json.Unmarshal(params[position], &block); err != nil {
+			return false
+		}
+	}
+	if isHash(block.BlockNumber) || isHash(block.BlockHash) {
+		return true
+	}
+	if !strings.HasPrefix(block.BlockNumber, "0x") {
+		// the tags and the invalid params
+		return false
+	}
+	blockNumber, err := strconv.ParseUint(block.BlockNumber[2:], 16, 64)
+	if err != nil {
+		return false
+	}
+	number := dataCollection.Uint64(blockNumber)
+	return h.confirmedTTL(&number) == config.CacheImmutableTime
+}
+
+// forwardRPC answers the calls with the third party api, with a batch request when they are more than one,
+// saving the results of the cacheable ones.
+func (h *Handler) forwardRPC(ctx context.Context, calls []rpcCall, responses []*dataCollection.Response) {
+	if len(calls) == 0 {
+		return
+	}
+	elems := make([]dataCollection.BatchElem, len(calls))
+	for i, call := range calls {
+		params := make([]interface{}, len(call.params))
+		for j, param := range call.params {
+			params[j] = param
+		}
+		elems[i] = dataCollection.BatchElem{Method: call.method, Params: params, Result: new(json.RawMessage)}
+	}
+	if len(elems) == 1 {
+		result, err := h.client.Forward(ctx, elems[0].Method, elems[0].Params...)
+		elems[0].Result, elems[0].Found, elems[0].Error = &result, err == nil && !bytes.Equal(result, []byte("null")), err
+	} else if err := h.client.BatchCall(ctx, elems); err != nil {
+		for i := range elems {
+			elems[i].Error = err
+		}
+	}
+	for i, call := range calls {
+		elem := elems[i]
+		switch {
+		case elem.Error != nil:
+			responses[call.index] = rpcErrorOf(elem.Error)
+		case !elem.Found:
+			// the null results are not saved as the block could still be unknown to the upstream
+			responses[call.index] = &dataCollection.Response{Result: json.RawMessage("null")}
+		default:
+			result := *elem.Result.(*json.RawMessage)
+			if call.key != "" && h.store != nil {
+				h.store.Set(call.key, result, time.Now().Add(config.CacheImmutableTime))
+			}
+			responses[call.index] = &dataCollection.Response{Result: result}
+		}
+	}
+}
+
+// rpcError returns the response with the JSON-RPC error.
+func rpcError(code int, message string) *dataCollection.Response {
+	return &dataCollection.Response{JSONRPC: "2.0", ID: json.RawMessage("null"), Error: &dataCollection.RPCError{Code: code, Message: message}}
+}
+
+// rpcErrorOf returns the response with the error of the upstream, as it was answered when it is a JSON-RPC error.
+func rpcErrorOf(err error) *dataCollection.Response {
+	var upstreamErr *dataCollection.RPCError
+	switch {
+	case errors.As(err, &upstreamErr):
+		return rpcError(upstreamErr.Code, upstreamErr.Message)
+	case errors.Is(err, dataCollection.ErrRateLimited):
+		return rpcError(codeLimitExceeded, err.Error())
+	default:
+		return rpcError(codeInternalError, err.Error())
+	}
+}
+
+// writeRPC writes the JSON-RPC response or batch of responses.
+func writeRPC(v interface{}, w http.ResponseWriter) {
+	body, _ := json.Marshal(v)
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write(body)
+}
@@ -0,0 +1,68 @@
+package API
+
+import (
+	"github.com/LucaPaterlini/infura/dataCollection"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestRPCHandler(t *testing.T) {
+	h, heads := newTestHandler(t)
+	defer heads.Stop()
+	testHandler(t, h.RPCHandler, testCasesRPC)
+}
+
+func TestRPCHandler_store(t *testing.T) {
+	client := dataCollection.NewClient(fakeUpstream{})
+	heads := newTestHeadTracker(t, client)
+	defer heads.Stop()
+	store := &mapStore{values: make(map[string][]byte)}
+	h := NewHandler(client, heads, store)
+	body := `[{"jsonrpc":"2.0","id":1,"method":"eth_getBlockByNumber","params":["0xc",false]},` +
+		`{"jsonrpc":"2.0","id":2,"method":"eth_getBlockByNumber","params":["0x7fc4a9",false]},` +
+		`{"jsonrpc":"2.0","id":3,"method":"eth_getBalance","params":["0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed",{"blockHash":"0x7277db362335d9cdbcf1ae9af8c6a4d0d2ef62be7cdc1c4b8fcb7f4a1f2c5a4e"}]},` +
+		`{"jsonrpc":"2.0","id":4,"method":"eth_getBlockByNumber","params":["latest",false]}]`
+	w := httptest.NewRecorder()
+	h.RPCHandler(w, httptest.NewRequest(http.MethodPost, "/v1/rpc", strings.NewReader(body)))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected: 200 got : %d %s", w.Code, w.Body)
+	}
+	// only the results at the confirmed block and at the block hash are stored
+	for _, key := range []string{`rpc:eth_getBlockByNumber["0xc",false]`,
+		`rpc:eth_getBalance["0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed",{"blockHash":"0x7277db362335d9cdbcf1ae9af8c6a4d0d2ef62be7cdc1c4b8fcb7f4a1f2c5a4e"}]`} {
+		if _, ok := store.values[key]; !ok {
+			t.Errorf("Expected: %s stored got : none", key)
+		}
+	}
+	if len(store.values) != 2 {
+		t.Errorf("Expected: 2 results stored got : %d", len(store.values))
+	}
+
+	// the stored results are served without reaching the upstream
+	h = NewHandler(dataCollection.NewClient(failingUpstream{statusCode: http.StatusInternalServerError}), heads, store)
+	w = httptest.NewRecorder()
+	body = `{"jsonrpc":"2.0","id":"x","method":"eth_getBlockByNumber","params":["0xc", false]}`
+	h.RPCHandler(w, httptest.NewRequest(http.MethodPost, "/v1/rpc", strings.NewReader(body)))
+	if expected := `{"jsonrpc":"2.0","id":"x","result":{`; !strings.HasPrefix(w.Body.String(), expected) {
+		t.Errorf("Expected: %s got : %s", expected, w.Body)
+	}
+}
+
+func TestRPCHandler_allowlist(t *testing.T) {
+	h, heads := newTestHandler(t)
+	defer heads.Stop()
+	h.AllowRPCMethods("eth_chainId")
+	for method, expected := range map[string]string{
+		"eth_chainId":     `{"jsonrpc":"2.0","id":1,"result":"0x1"}`,
+		"eth_blockNumber": `{"jsonrpc":"2.0","id":1,"error":{"code":-32601,"message":"the method eth_blockNumber is not available"}}`,
+	} {
+		w := httptest.NewRecorder()
+		body := `{"jsonrpc":"2.0","id":1,"method":"` + method + `"}`
+		h.RPCHandler(w, httptest.NewRequest(http.MethodPost, "/v1/rpc", strings.NewReader(body)))
+		if w.Body.String() != expected {
+			t.Errorf("Expected: %s got : %s", expected, w.Body)
+		}
+	}
+}
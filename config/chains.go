@@ -0,0 +1,171 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"regexp"
+	"time"
+)
+
+const (
+	sepoliaURL   = "https://sepolia.infura.io"
+	sepoliaWSURL = "wss://sepolia.infura.io/ws"
+	holeskyURL   = "https://holesky.infura.io"
+	holeskyWSURL = "wss://holesky.infura.io/ws"
+	// DefaultChain the name of the chain served by the routes without the chain
+	DefaultChain = "mainnet"
+	// LimiterRate the new requests allowed to each user each second on each chain
+	LimiterRate = 10
+	// LimiterBurst the requests allowed to each user at once on each chain
+	LimiterBurst = 15
+	// BlockTime the interval between two blocks of the chains that do not set it, at which their last block is polled
+	BlockTime = 12 * time.Second
+)
+
+// chainNamePattern are the names of the chains, that can not be confused with the first segment of the routes.
+var chainNamePattern = regexp.MustCompile("^[a-z][a-z0-9-]*$")
+
+// reservedNames are the first segments of the routes of each chain.
+var reservedNames = map[string]bool{
+	"block": true, "blocks": true, "tx": true, "logs": true, "account": true, "call": true, "rpc": true, "status": true,
+}
+
+// Duration is a time.Duration encoded in JSON as its string, such as "12s".
+type Duration time.Duration
+
+// MarshalJSON encodes the duration as its string.
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+// UnmarshalJSON decodes the duration from its string.
+func (d *Duration) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err != nil {
+		return err
+	}
+	duration, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	*d = Duration(duration)
+	return nil
+}
+
+// Chain is a chain served by the api, its data is collected from the upstreams, in order of preference,
+// its last block is followed through the WebSocket endpoint WS, when it has one, and its requests are limited
+// to RateLimit each second with bursts of RateBurst for each user.
+type Chain struct {
+	ID                uint64   `json:"id"`
+	Name              string   `json:"name"`
+	Upstreams         []string `json:"upstreams"`
+	WS                string   `json:"ws"`
+	ConfirmationDepth uint64   `json:"confirmationDepth"`
+	BlockTime         Duration `json:"blockTime"`
+	RateLimit         float64  `json:"rateLimit"`
+	RateBurst         int      `json:"rateBurst"`
+}
+
+// Registry lists the chains served by the api, the Default one is also served by the routes without the chain.
+type Registry struct {
+	Default string  `json:"default"`
+	Chains  []Chain `json:"chains"`
+}
+
+// DefaultRegistry returns the registry of mainnet, Sepolia and Holesky through the 3rd party api.
+func DefaultRegistry() Registry {
+	return Registry{
+		Default: DefaultChain,
+		Chains: []Chain{
+			{ID: 1, Name: DefaultChain, Upstreams: []string{FullMainNetPath}, WS: FullMainNetWSPath,
+				ConfirmationDepth: ConfirmationDepth, BlockTime: Duration(BlockTime), RateLimit: LimiterRate, RateBurst: LimiterBurst},
+			{ID: 11155111, Name: "sepolia", Upstreams: []string{sepoliaURL + "/" + version + "/" + projectID}, WS: sepoliaWSURL + "/" + version + "/" + projectID,
+				ConfirmationDepth: ConfirmationDepth, BlockTime: Duration(BlockTime), RateLimit: LimiterRate, RateBurst: LimiterBurst},
+			{ID: 17000, Name: "holesky", Upstreams: []string{holeskyURL + "/" + version + "/" + projectID}, WS: holeskyWSURL + "/" + version + "/" + projectID,
+				ConfirmationDepth: ConfirmationDepth, BlockTime: Duration(BlockTime), RateLimit: LimiterRate, RateBurst: LimiterBurst},
+		},
+	}
+}
+
+// LoadRegistry returns the default registry with the chains of the JSON file at path added,
+// replacing the default chains with the same name, and the default chain of the file if it sets one.
+func LoadRegistry(path string) (Registry, error) {
+	registry := DefaultRegistry()
+	if path == "" {
+		return registry, nil
+	}
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return registry, err
+	}
+	var loaded Registry
+	if err := json.Unmarshal(b, &loaded); err != nil {
+		return registry, fmt.Errorf("invalid chains file %s: %s", path, err)
+	}
+	if loaded.Default != "" {
+		registry.Default = loaded.Default
+	}
+	for _, chain := range loaded.Chains {
+		registry.Add(chain)
+	}
+	return registry, registry.Validate()
+}
+
+// Add adds the chain to the registry, replacing the one with the same name,
+// the confirmation depth, the block time and the rate limits not set are the default ones,
+// as a depth of 0 would cache the head of the chain as immutable.
+func (r *Registry) Add(chain Chain) {
+	if chain.ConfirmationDepth == 0 {
+		chain.ConfirmationDepth = ConfirmationDepth
+	}
+	if chain.BlockTime <= 0 {
+		chain.BlockTime = Duration(BlockTime)
+	}
+	if chain.RateLimit == 0 {
+		chain.RateLimit = LimiterRate
+	}
+	if chain.RateBurst == 0 {
+		chain.RateBurst = LimiterBurst
+	}
+	for i := range r.Chains {
+		if r.Chains[i].Name == chain.Name {
+			r.Chains[i] = chain
+			return
+		}
+	}
+	r.Chains = append(r.Chains, chain)
+}
+
+// Chain returns the chain with the given name.
+func (r Registry) Chain(name string) (Chain, bool) {
+	for _, chain := range r.Chains {
+		if chain.Name == name {
+			return chain, true
+		}
+	}
+	return Chain{}, false
+}
+
+// Validate checks that the names and ids of the chains are unique, that their names can be used in the routes,
+// that each one has an upstream and that the default chain is one of them.
+func (r Registry) Validate() error {
+	names, ids := make(map[string]bool), make(map[uint64]bool)
+	for _, chain := range r.Chains {
+		switch {
+		case !chainNamePattern.MatchString(chain.Name) || reservedNames[chain.Name]:
+			return fmt.Errorf("invalid chain name %q", chain.Name)
+		case names[chain.Name]:
+			return fmt.Errorf("duplicated chain name %q", chain.Name)
+		case ids[chain.ID]:
+			return fmt.Errorf("duplicated chain id %d", chain.ID)
+		case len(chain.Upstreams) == 0:
+			return fmt.Errorf("chain %s without upstreams", chain.Name)
+		}
+		names[chain.Name], ids[chain.ID] = true, true
+	}
+	if !names[r.Default] {
+		return fmt.Errorf("unknown default chain %q", r.Default)
+	}
+	return nil
+}
@@ -0,0 +1,74 @@
+package config
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestLoadRegistry(t *testing.T) {
+	dir, err := ioutil.TempDir("", "chains")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "chains.json")
+	body := `{"default":"optimism","chains":[` +
+		`{"id":10,"name":"optimism","upstreams":["https://optimism.example"],"confirmationDepth":300,"blockTime":"2s","rateLimit":50},` +
+		`{"id":8453,"name":"base","upstreams":["https://base.example"]},` +
+		`{"id":11155111,"name":"sepolia","upstreams":["https://sepolia.example"],"confirmationDepth":64,"blockTime":"12s"}]}`
+	if err := ioutil.WriteFile(path, []byte(body), 0600); err != nil {
+		t.Fatal(err)
+	}
+	registry, err := LoadRegistry(path)
+	if err != nil {
+		t.Fatalf("unexpected error %s", err)
+	}
+	if registry.Default != "optimism" || len(registry.Chains) != 5 {
+		t.Errorf("Expected: 5 chains with the default optimism got : %d %s", len(registry.Chains), registry.Default)
+	}
+	optimism, ok := registry.Chain("optimism")
+	if !ok || time.Duration(optimism.BlockTime) != 2*time.Second || optimism.RateLimit != 50 || optimism.RateBurst != LimiterBurst {
+		t.Errorf("unexpected chain %+v", optimism)
+	}
+	// the chains without the confirmation depth and the block time get the default ones, not the head as immutable
+	base, _ := registry.Chain("base")
+	if base.ConfirmationDepth != ConfirmationDepth || time.Duration(base.BlockTime) != BlockTime {
+		t.Errorf("unexpected chain %+v", base)
+	}
+	// the chains of the file replace the default ones with the same name
+	if sepolia, _ := registry.Chain("sepolia"); sepolia.Upstreams[0] != "https://sepolia.example" || sepolia.ConfirmationDepth != 64 {
+		t.Errorf("unexpected chain %+v", sepolia)
+	}
+	if mainnet, _ := registry.Chain(DefaultChain); mainnet.Upstreams[0] != FullMainNetPath {
+		t.Errorf("unexpected chain %+v", mainnet)
+	}
+}
+
+func TestRegistry_Validate(t *testing.T) {
+	if err := DefaultRegistry().Validate(); err != nil {
+		t.Errorf("unexpected error %s", err)
+	}
+	for _, tc := range []struct {
+		chain    Chain
+		expected string
+	}{
+		{chain: Chain{ID: 10, Name: "block", Upstreams: []string{"https://l2.example"}}, expected: `invalid chain name "block"`},
+		{chain: Chain{ID: 10, Name: "Optimism", Upstreams: []string{"https://l2.example"}}, expected: `invalid chain name "Optimism"`},
+		{chain: Chain{ID: 1, Name: "fork", Upstreams: []string{"https://l2.example"}}, expected: "duplicated chain id 1"},
+		{chain: Chain{ID: 10, Name: "optimism"}, expected: "chain optimism without upstreams"},
+	} {
+		registry := DefaultRegistry()
+		registry.Add(tc.chain)
+		if err := registry.Validate(); err == nil || err.Error() != tc.expected {
+			t.Errorf("Expected: %s got : %v", tc.expected, err)
+		}
+	}
+	registry := DefaultRegistry()
+	registry.Default = "optimism"
+	if err := registry.Validate(); err == nil {
+		t.Error("Expected: unknown default chain got : nil")
+	}
+}
@@ -3,19 +3,106 @@ package config
 import "time"
 
 const (
-	mainNetURL = "https://mainnet.infura.io"
-	version    = "v3"
-	projectID  = "189d58fe65f14de6bbe6cb78c0ce8aea"
+	mainNetURL   = "https://mainnet.infura.io"
+	mainNetWSURL = "wss://mainnet.infura.io/ws"
+	version      = "v3"
+	projectID    = "189d58fe65f14de6bbe6cb78c0ce8aea"
 	// FullMainNetPath contain the main url of the path to call to access the 3rd party api.
 	FullMainNetPath = mainNetURL + "/" + version + "/" + projectID
+	// FullMainNetWSPath contain the url of the WebSocket endpoint of the 3rd party api, used to subscribe to the new blocks.
+	FullMainNetWSPath = mainNetWSURL + "/" + version + "/" + projectID
 	// DefaultRequestsTimeout contains the timeout time for the request of the 3rd party api.
 	DefaultRequestsTimeout = 2 * time.Second
 	//CacheSize size of the cache to use to store the api call to the 3rd party api
 	CacheSize = 100 * 1024 * 1024
+	// CacheDiskSize size of the cache on disk of the api calls whose result can not change anymore, kept across the restarts
+	CacheDiskSize = 1024 * 1024 * 1024
+	// CacheDiskPath the file of the cache on disk
+	CacheDiskPath = "cache.db"
+	// CacheDiskMinTime the minimum ttl of the api calls stored also on disk, the ones cached indefinitely
+	CacheDiskMinTime = 24 * time.Hour
+	// CacheRedisURL the url of the Redis server shared by the replicas, used when the cache backend is redis
+	CacheRedisURL = "redis://localhost:6379/0"
+	// CacheRedisNamespace the prefix of the keys of the responses in Redis, followed by the chain
+	CacheRedisNamespace = "infura"
+	// CacheRedisPoolSize the connections kept open towards Redis
+	CacheRedisPoolSize = 64
+	// CacheRedisTimeout the timeout of each command sent to Redis
+	CacheRedisTimeout = 500 * time.Millisecond
 	//CacheExpireTime the ttl of the api calls cached
 	CacheExpireTime = time.Minute
+	// CacheImmutableTime the ttl of the api calls whose result can not change anymore, such as a confirmed transaction,
+	// cached indefinitely as long as the cache has room for them
+	CacheImmutableTime = 365 * 24 * time.Hour
+	// CacheUnconfirmedTime the ttl of the api calls whose result can still change with a reorg, about one block
+	CacheUnconfirmedTime = 12 * time.Second
+	// CacheLatestTime the ttl of the api calls at the latest block, that is replaced by every new block
+	CacheLatestTime = 2 * time.Second
+	// ConfirmationDepth the blocks mined on top of a block after which it is considered immutable
+	ConfirmationDepth = 12
 	//CacheUpdateLastBlockTime the ticker to update the value of the last block of the eth chain"
 	CacheUpdateLastBlockTime = time.Minute
+	// HeadPollTime the ticker to poll the last block of the eth chain while the newHeads subscription is down
+	HeadPollTime = 5 * time.Second
+	// HeadReconnectTime the wait before subscribing again to the newHeads after the socket dropped
+	HeadReconnectTime = 30 * time.Second
+	// HeadStaleTime the time without new blocks after which the newHeads subscription is considered dropped
+	HeadStaleTime = time.Minute
+	// ReorgDepth the last blocks whose hashes are tracked to detect the reorgs of the chain
+	ReorgDepth = 64
+	// UpstreamMaxIdleConns the maximum number of idle connections kept open towards all the upstreams
+	UpstreamMaxIdleConns = 256
+	// UpstreamMaxIdleConnsPerHost the maximum number of idle connections kept open towards each upstream
+	UpstreamMaxIdleConnsPerHost = 64
+	// UpstreamHealthCheckTime the ticker to probe the health of each upstream endpoint
+	UpstreamHealthCheckTime = 15 * time.Second
+	// RetryMaxAttempts the maximum number of attempts for each request to the 3rd party api
+	RetryMaxAttempts = 3
+	// RetryBaseBackoff the wait before the first retry of a failed request, doubled at every retry
+	RetryBaseBackoff = 100 * time.Millisecond
+	// RetryMaxBackoff the maximum wait between two attempts of the same request
+	RetryMaxBackoff = time.Second
+	// BreakerWindow the interval over which the error rate of each upstream endpoint is computed
+	BreakerWindow = 10 * time.Second
+	// BreakerMinRequests the requests required in the window before the circuit breaker evaluates the error rate
+	BreakerMinRequests = 10
+	// BreakerErrorRate the fraction of failed requests in the window that opens the circuit breaker
+	BreakerErrorRate = 0.5
+	// BreakerSlowCall the latency above which a request to the 3rd party api counts as failed
+	BreakerSlowCall = time.Second
+	// BreakerOpenTimeout how long the circuit breaker stays open before letting trial requests through
+	BreakerOpenTimeout = 30 * time.Second
+	// BreakerHalfOpenRequests the successful trial requests that close the circuit breaker again
+	BreakerHalfOpenRequests = 3
+	// ReceiptsConcurrency the maximum number of receipts requested at the same time to the 3rd party api
+	// when it does not support eth_getBlockReceipts
+	ReceiptsConcurrency = 8
+	// LogsChunkSize the blocks of each eth_getLogs request a range of logs is split into
+	LogsChunkSize = 2000
+	// LogsConcurrency the maximum number of chunks of a range of logs requested at the same time to the 3rd party api
+	LogsConcurrency = 4
+	// LogsMaxRange the maximum number of blocks of a range of logs
+	LogsMaxRange = 100000
+	// LogsRequestsTimeout contains the timeout time for retrieving a range of logs from the 3rd party api.
+	LogsRequestsTimeout = 10 * time.Second
+	// BlocksMaxRange the maximum number of blocks of a range of blocks
+	BlocksMaxRange = 1000
+	// BlocksBatchSize the blocks of a range of blocks requested with each JSON-RPC batch request
+	BlocksBatchSize = 50
+	// BlocksRequestsTimeout contains the timeout time for streaming a range of blocks.
+	BlocksRequestsTimeout = time.Minute
+	// CallMaxBodySize the maximum size in bytes of the body of a call request
+	CallMaxBodySize = 1 << 20
+	// RPCMaxBodySize the maximum size in bytes of the body of a JSON-RPC request
+	RPCMaxBodySize = 1 << 20
+	// RPCMaxBatchSize the maximum number of requests of a JSON-RPC batch
+	RPCMaxBatchSize = 100
+	// RPCAllowedMethods the comma separated JSON-RPC methods served by default, the read only ones
+	RPCAllowedMethods = "eth_blockNumber,eth_chainId,net_version,eth_gasPrice,eth_maxPriorityFeePerGas,eth_feeHistory," +
+		"eth_getBlockByNumber,eth_getBlockByHash,eth_getBlockTransactionCountByNumber,eth_getBlockTransactionCountByHash," +
+		"eth_getTransactionByHash,eth_getTransactionByBlockNumberAndIndex,eth_getTransactionByBlockHashAndIndex," +
+		"eth_getTransactionReceipt,eth_getBlockReceipts,eth_getBalance,eth_getTransactionCount,eth_getCode," +
+		"eth_getStorageAt,eth_call,eth_estimateGas,eth_getLogs"
 	// DefaultAddr contains the default address to bind to run the api server.
 	DefaultAddr = ":8123"
 )
@@ -0,0 +1,102 @@
+package dataCollection
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+)
+
+// The block tags accepted in place of a block number.
+const (
+	TagLatest    = "latest"
+	TagEarliest  = "earliest"
+	TagPending   = "pending"
+	TagSafe      = "safe"
+	TagFinalized = "finalized"
+)
+
+// BlockRef identifies the block the state of an account is read at, by number, by hash as defined by EIP-1898,
+// or by tag, in this order of precedence.
+type BlockRef struct {
+	Number *uint64
+	Hash   string
+	Tag    string
+}
+
+// BlockNumberRef returns the reference to the block with the given number.
+func BlockNumberRef(blockNumber uint64) BlockRef {
+	return BlockRef{Number: &blockNumber}
+}
+
+// MarshalJSON encodes the reference as the block parameter of the JSON-RPC state methods.
+func (b BlockRef) MarshalJSON() ([]byte, error) {
+	switch {
+	case b.Number != nil:
+		return json.Marshal(Uint64(*b.Number))
+	case b.Hash != "":
+		return json.Marshal(struct {
+			BlockHash string `json:"blockHash"`
+		}{b.Hash})
+	case b.Tag != "":
+		return json.Marshal(b.Tag)
+	default:
+		return json.Marshal(TagLatest)
+	}
+}
+
+func (b BlockRef) String() string {
+	switch {
+	case b.Number != nil:
+		return fmt.Sprint(*b.Number)
+	case b.Hash != "":
+		return b.Hash
+	case b.Tag != "":
+		return b.Tag
+	default:
+		return TagLatest
+	}
+}
+
+// state executes the JSON-RPC state method decoding its result into result,
+// a null result means that the block does not exist.
+func (c *Client) state(ctx context.Context, result interface{}, method string, params ...interface{}) error {
+	found, err := c.call(ctx, result, method, params...)
+	if err == nil && !found {
+		err = fmt.Errorf("%w: %s returned no result", ErrNotFound, method)
+	}
+	return err
+}
+
+// GetBalance using the third party api gets the balance in wei of the address at the given block,
+// if the third party is not able to provide an answer before the context is done it returns timeout error.
+func (c *Client) GetBalance(ctx context.Context, address string, block BlockRef) (*Quantity, error) {
+	balance := new(Quantity)
+	if err := c.state(ctx, balance, "eth_getBalance", address, block); err != nil {
+		return nil, err
+	}
+	return balance, nil
+}
+
+// GetTransactionCount using the third party api gets the nonce of the address at the given block,
+// if the third party is not able to provide an answer before the context is done it returns timeout error.
+func (c *Client) GetTransactionCount(ctx context.Context, address string, block BlockRef) (uint64, error) {
+	var nonce Uint64
+	err := c.state(ctx, &nonce, "eth_getTransactionCount", address, block)
+	return uint64(nonce), err
+}
+
+// GetCode using the third party api gets the hex encoded code deployed at the address at the given block,
+// if the third party is not able to provide an answer before the context is done it returns timeout error.
+func (c *Client) GetCode(ctx context.Context, address string, block BlockRef) (string, error) {
+	var code string
+	err := c.state(ctx, &code, "eth_getCode", address, block)
+	return code, err
+}
+
+// GetStorageAt using the third party api gets the hex encoded value of the storage slot of the address at the given block,
+// if the third party is not able to provide an answer before the context is done it returns timeout error.
+func (c *Client) GetStorageAt(ctx context.Context, address, slot string, block BlockRef) (string, error) {
+	var value string
+	err := c.state(ctx, &value, "eth_getStorageAt", address, slot, block)
+	return value, err
+}
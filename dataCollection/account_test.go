@@ -0,0 +1,61 @@
+package dataCollection
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/go-test/deep"
+	"testing"
+)
+
+func TestBlockRef_MarshalJSON(t *testing.T) {
+	for _, tc := range []struct {
+		block    BlockRef
+		expected string
+	}{
+		{block: BlockRef{}, expected: `"latest"`},
+		{block: BlockRef{Tag: TagSafe}, expected: `"safe"`},
+		{block: BlockNumberRef(0), expected: `"0x0"`},
+		{block: BlockNumberRef(8368161), expected: `"0x7fb021"`},
+		{block: BlockRef{Hash: "0x7277db362335d9cdbcf1ae9af8c6a4d0d2ef62be7cdc1c4b8fcb7f4a1f2c5a4e"}, expected: `{"blockHash":"0x7277db362335d9cdbcf1ae9af8c6a4d0d2ef62be7cdc1c4b8fcb7f4a1f2c5a4e"}`},
+	} {
+		got, err := json.Marshal(tc.block)
+		if err != nil {
+			t.Fatalf("unexpected error %s", err)
+		}
+		if string(got) != tc.expected {
+			t.Errorf("Expected: %s got : %s", tc.expected, got)
+		}
+	}
+}
+
+func TestGetAccountState(t *testing.T) {
+	ts := newTestUpstream()
+	defer ts.Close()
+	client := NewClient(NewHTTPUpstream(ts.URL))
+	for _, tc := range testCasesGetAccountState {
+		var result interface{}
+		var err error
+		ctx := context.Background()
+		switch tc.method {
+		case "balance":
+			result, err = client.GetBalance(ctx, tc.address, tc.block)
+		case "nonce":
+			result, err = client.GetTransactionCount(ctx, tc.address, tc.block)
+		case "code":
+			result, err = client.GetCode(ctx, tc.address, tc.block)
+		case "storage":
+			result, err = client.GetStorageAt(ctx, tc.address, "0x0", tc.block)
+		}
+		if tc.expectedError != nil || err != nil {
+			if !errors.Is(err, tc.expectedError) {
+				t.Errorf("Test:%s Expected: %v got : %v", tc.description, tc.expectedError, err)
+			}
+			continue
+		}
+		gotResult, _ := json.Marshal(result)
+		if diffList := deep.Equal(tc.expectedResult, string(gotResult)); len(diffList) > 0 {
+			t.Errorf("Test:%s\nDiff    : %v\n", tc.description, diffList)
+		}
+	}
+}
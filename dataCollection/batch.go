@@ -0,0 +1,123 @@
+package dataCollection
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+// BatchElem is a call of a JSON-RPC batch, its result is decoded into Result,
+// while Found and Error are set once the batch has been executed.
+type BatchElem struct {
+	Method string
+	Params []interface{}
+	Result interface{}
+	Found  bool
+	Error  error
+}
+
+// BatchCall executes the calls with a single JSON-RPC batch request, retried as a whole as the single calls.
+// The error returned is the failure of the whole batch, the failure of each call is set in its Error.
+func (c *Client) BatchCall(ctx context.Context, elems []BatchElem) error {
+	if len(elems) == 0 {
+		return nil
+	}
+	reqs := make([]request, len(elems))
+	for i, elem := range elems {
+		params := elem.Params
+		if params == nil {
+			params = []interface{}{}
+		}
+		reqs[i] = request{JSONRPC: "2.0", Method: elem.Method, Params: params, ID: i + 1}
+	}
+	jsonStr, err := json.Marshal(reqs)
+	if err != nil {
+		return err
+	}
+	return c.send(ctx, jsonStr, func(statusCode int, body []byte, err error) error {
+		return decodeBatch(statusCode, body, err, elems)
+	})
+}
+
+// decodeBatch decodes the responses of a batch into the calls with their ids, in any order,
+// a single response instead of a list reports the failure of the whole batch.
+func decodeBatch(statusCode int, body []byte, err error, elems []BatchElem) error {
+	if err != nil {
+		return transportError(err)
+	}
+	if statusCode == http.StatusTooManyRequests {
+		return statusError(statusCode, nil)
+	}
+	var responses []Response
+	if err := json.Unmarshal(body, &responses); err != nil {
+		var single Response
+		if json.Unmarshal(body, &single) == nil && single.Error != nil {
+			return single.Error
+		}
+		return statusError(statusCode, fmt.Errorf("invalid JSON-RPC batch response: %s", err))
+	}
+	for i := range elems {
+		elems[i].Found = false
+		elems[i].Error = fmt.Errorf("%w: no response to %s", ErrUpstreamInternal, elems[i].Method)
+	}
+	for _, resp := range responses {
+		var id int
+		if json.Unmarshal(resp.ID, &id) != nil || id < 1 || id > len(elems) {
+			continue
+		}
+		elem := &elems[id-1]
+		elem.Error = nil
+		switch {
+		case resp.Error != nil:
+			elem.Error = resp.Error
+		case len(resp.Result) == 0 || bytes.Equal(resp.Result, []byte("null")):
+		default:
+			if err := json.Unmarshal(resp.Result, elem.Result); err != nil {
+				elem.Error = fmt.Errorf("%w: invalid JSON-RPC result: %s", ErrUpstreamInternal, err)
+			} else {
+				elem.Found = true
+			}
+		}
+	}
+	return nil
+}
+
+// Forward using the third party api executes the JSON-RPC method with the given params and returns its raw result,
+// null when the upstream answered with a null result.
+// If the third party is not able to provide an answer before the context is done it returns timeout error.
+func (c *Client) Forward(ctx context.Context, method string, params ...interface{}) (json.RawMessage, error) {
+	var result json.RawMessage
+	found, err := c.call(ctx, &result, method, params...)
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return json.RawMessage("null"), nil
+	}
+	return result, nil
+}
+
+// GetBlocks using the third party api gets the data of the requested blocks with a single batch request,
+// with the full transactions when requested, nil for the blocks that do not exist.
+// If the third party is not able to provide an answer before the context is done it returns timeout error.
+func (c *Client) GetBlocks(ctx context.Context, blockNumbers []uint64, full bool) ([]*Block, error) {
+	elems := make([]BatchElem, len(blockNumbers))
+	for i, blockNumber := range blockNumbers {
+		elems[i] = BatchElem{Method: "eth_getBlockByNumber", Params: []interface{}{Uint64(blockNumber), full}, Result: new(Block)}
+	}
+	if err := c.BatchCall(ctx, elems); err != nil {
+		return nil, err
+	}
+	blocks := make([]*Block, len(elems))
+	for i, elem := range elems {
+		if elem.Error != nil {
+			return nil, fmt.Errorf("block %d: %w", blockNumbers[i], elem.Error)
+		}
+		if elem.Found {
+			blocks[i] = elem.Result.(*Block)
+		}
+	}
+	return blocks, nil
+}
@@ -0,0 +1,88 @@
+package dataCollection
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"testing"
+)
+
+func TestBatchCall(t *testing.T) {
+	// the responses of a batch can be in any order
+	ts := newStaticUpstream(http.StatusOK, `[`+
+		`{"jsonrpc":"2.0","id":3,"error":{"code":-32602,"message":"invalid argument 0"}},`+
+		`{"jsonrpc":"2.0","id":2,"result":null},`+
+		`{"jsonrpc":"2.0","id":1,"result":"0x7fb25b"}]`)
+	defer ts.Close()
+	var blockNumber Uint64
+	elems := []BatchElem{
+		{Method: "eth_blockNumber", Result: &blockNumber},
+		{Method: "eth_getBlockByNumber", Params: []interface{}{Uint64(1 << 40), false}, Result: new(Block)},
+		{Method: "eth_getBlockByNumber", Params: []interface{}{"newest", false}, Result: new(Block)},
+		{Method: "eth_chainId", Result: new(Uint64)},
+	}
+	if err := NewClient(NewHTTPUpstream(ts.URL)).BatchCall(context.Background(), elems); err != nil {
+		t.Fatalf("unexpected error %s", err)
+	}
+	if !elems[0].Found || elems[0].Error != nil || blockNumber != 0x7fb25b {
+		t.Errorf("Expected: %d got : %d %v", 0x7fb25b, blockNumber, elems[0].Error)
+	}
+	if elems[1].Found || elems[1].Error != nil {
+		t.Errorf("Expected: not found got : %t %v", elems[1].Found, elems[1].Error)
+	}
+	if !errors.Is(elems[2].Error, ErrInvalidParams) {
+		t.Errorf("Expected: %v got : %v", ErrInvalidParams, elems[2].Error)
+	}
+	if !errors.Is(elems[3].Error, ErrUpstreamInternal) {
+		t.Errorf("Expected: the missing response %v got : %v", ErrUpstreamInternal, elems[3].Error)
+	}
+}
+
+func TestBatchCall_errors(t *testing.T) {
+	for _, tc := range []struct {
+		body     string
+		expected error
+	}{
+		{body: `{"jsonrpc":"2.0","id":null,"error":{"code":-32005,"message":"daily request count exceeded"}}`, expected: ErrRateLimited},
+		{body: `<html>bad gateway</html>`, expected: ErrUpstreamInternal},
+	} {
+		ts := newStaticUpstream(http.StatusOK, tc.body)
+		client := NewClient(NewHTTPUpstream(ts.URL))
+		client.Retry.MaxAttempts = 1
+		err := client.BatchCall(context.Background(), []BatchElem{{Method: "eth_blockNumber", Result: new(Uint64)}})
+		ts.Close()
+		if !errors.Is(err, tc.expected) {
+			t.Errorf("Expected: %v got : %v", tc.expected, err)
+		}
+	}
+}
+
+func TestGetBlocks(t *testing.T) {
+	ts := newStaticUpstream(http.StatusOK, `[{"jsonrpc":"2.0","id":2,"result":null},{"jsonrpc":"2.0","id":1,"result":`+resultOf(recordedBlock8368161)+`}]`)
+	defer ts.Close()
+	blocks, err := NewClient(NewHTTPUpstream(ts.URL)).GetBlocks(context.Background(), []uint64{8368161, 1 << 40}, false)
+	if err != nil {
+		t.Fatalf("unexpected error %s", err)
+	}
+	if len(blocks) != 2 || blocks[0] == nil || blocks[0].Number != 8368161 || blocks[1] != nil {
+		t.Errorf("Expected: the block 8368161 and nil got : %v", blocks)
+	}
+}
+
+func TestForward(t *testing.T) {
+	for _, tc := range []struct {
+		body     string
+		expected string
+	}{
+		{body: `{"jsonrpc":"2.0","id":1,"result":{"number":"0xc"}}`, expected: `{"number":"0xc"}`},
+		{body: `{"jsonrpc":"2.0","id":1,"result":null}`, expected: `null`},
+	} {
+		ts := newStaticUpstream(http.StatusOK, tc.body)
+		result, err := NewClient(NewHTTPUpstream(ts.URL)).Forward(context.Background(), "eth_getBlockByNumber", json.RawMessage(`"0xc"`), false)
+		ts.Close()
+		if err != nil || string(result) != tc.expected {
+			t.Errorf("Expected: %s got : %s %v", tc.expected, result, err)
+		}
+	}
+}
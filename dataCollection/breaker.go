@@ -0,0 +1,181 @@
+package dataCollection
+
+import (
+	"context"
+	"errors"
+	"github.com/LucaPaterlini/infura/config"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// ErrCircuitOpen is returned without contacting the upstream while its circuit breaker is open.
+var ErrCircuitOpen = errors.New("upstream circuit breaker open")
+
+// BreakerState is the state of a CircuitBreaker.
+type BreakerState int
+
+// The states of a CircuitBreaker, closed lets every request through, open rejects them
+// and half-open lets through a few trial requests to verify if the upstream recovered.
+const (
+	BreakerClosed BreakerState = iota
+	BreakerOpen
+	BreakerHalfOpen
+)
+
+func (s BreakerState) String() string {
+	switch s {
+	case BreakerOpen:
+		return "open"
+	case BreakerHalfOpen:
+		return "half-open"
+	default:
+		return "closed"
+	}
+}
+
+// BreakerSettings configures when a CircuitBreaker opens and how it recovers.
+type BreakerSettings struct {
+	// Window is the interval over which the error rate is computed.
+	Window time.Duration
+	// MinRequests is the number of requests in the window required before the error rate is evaluated.
+	MinRequests int
+	// ErrorRate is the fraction of failed requests in the window that opens the circuit.
+	ErrorRate float64
+	// SlowCall is the latency above which a successful request is counted as failed.
+	SlowCall time.Duration
+	// OpenTimeout is how long the circuit stays open before letting trial requests through.
+	OpenTimeout time.Duration
+	// HalfOpenRequests is the number of successful trial requests that closes the circuit again.
+	HalfOpenRequests int
+}
+
+// DefaultBreakerSettings are the settings of the circuit breakers set in the config.
+var DefaultBreakerSettings = BreakerSettings{
+	Window:           config.BreakerWindow,
+	MinRequests:      config.BreakerMinRequests,
+	ErrorRate:        config.BreakerErrorRate,
+	SlowCall:         config.BreakerSlowCall,
+	OpenTimeout:      config.BreakerOpenTimeout,
+	HalfOpenRequests: config.BreakerHalfOpenRequests,
+}
+
+// CircuitBreaker is the Upstream that wraps an endpoint and stops sending it requests
+// when too many of them fail or are too slow, failing fast with ErrCircuitOpen.
+type CircuitBreaker struct {
+	upstream Upstream
+	name     string
+	settings BreakerSettings
+
+	mtx         sync.Mutex
+	state       BreakerState
+	openedAt    time.Time
+	windowStart time.Time
+	requests    int
+	failures    int
+	trials      int
+	successes   int
+}
+
+// NewCircuitBreaker returns the CircuitBreaker around the upstream, the name is used in the logs.
+func NewCircuitBreaker(name string, upstream Upstream, settings BreakerSettings) *CircuitBreaker {
+	return &CircuitBreaker{upstream: upstream, name: name, settings: settings, windowStart: time.Now()}
+}
+
+// State returns the current state of the circuit.
+func (b *CircuitBreaker) State() BreakerState {
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+	b.expireOpen(time.Now())
+	return b.state
+}
+
+// Post sends the request to the upstream unless the circuit is open, recording the outcome,
+// the requests canceled by the caller are not recorded.
+func (b *CircuitBreaker) Post(ctx context.Context, jsonStr []byte) (statusCode int, header map[string][]string, body []byte, err error) {
+	if !b.allow() {
+		err = ErrCircuitOpen
+		return
+	}
+	start := time.Now()
+	statusCode, header, body, err = b.upstream.Post(ctx, jsonStr)
+	if errors.Is(err, context.Canceled) {
+		b.release()
+		return
+	}
+	failed := err != nil || statusCode >= http.StatusInternalServerError || time.Since(start) > b.settings.SlowCall
+	b.record(failed)
+	return
+}
+
+// release gives back the trial taken by a request that ended without an outcome.
+func (b *CircuitBreaker) release() {
+	b.mtx.Lock()
+	if b.state == BreakerHalfOpen && b.trials > 0 {
+		b.trials--
+	}
+	b.mtx.Unlock()
+}
+
+// allow reports if a request can be sent, counting the trial requests while half-open.
+func (b *CircuitBreaker) allow() bool {
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+	b.expireOpen(time.Now())
+	switch b.state {
+	case BreakerOpen:
+		return false
+	case BreakerHalfOpen:
+		if b.trials >= b.settings.HalfOpenRequests {
+			return false
+		}
+		b.trials++
+	}
+	return true
+}
+
+// record updates the state of the circuit with the outcome of a request.
+func (b *CircuitBreaker) record(failed bool) {
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+	now := time.Now()
+	switch b.state {
+	case BreakerHalfOpen:
+		if failed {
+			b.setState(BreakerOpen, now)
+			return
+		}
+		b.successes++
+		if b.successes >= b.settings.HalfOpenRequests {
+			b.setState(BreakerClosed, now)
+		}
+	case BreakerClosed:
+		if now.Sub(b.windowStart) > b.settings.Window {
+			b.windowStart, b.requests, b.failures = now, 0, 0
+		}
+		b.requests++
+		if failed {
+			b.failures++
+		}
+		if b.requests >= b.settings.MinRequests && float64(b.failures)/float64(b.requests) >= b.settings.ErrorRate {
+			b.setState(BreakerOpen, now)
+		}
+	}
+}
+
+// expireOpen moves an open circuit to half-open once the open timeout has elapsed.
+func (b *CircuitBreaker) expireOpen(now time.Time) {
+	if b.state == BreakerOpen && now.Sub(b.openedAt) >= b.settings.OpenTimeout {
+		b.setState(BreakerHalfOpen, now)
+	}
+}
+
+// setState moves the circuit to the given state resetting its counters.
+func (b *CircuitBreaker) setState(state BreakerState, now time.Time) {
+	log.Printf("upstream %s circuit breaker %s -> %s", b.name, b.state, state)
+	b.state = state
+	b.openedAt = now
+	b.windowStart, b.requests, b.failures = now, 0, 0
+	b.trials, b.successes = 0, 0
+}
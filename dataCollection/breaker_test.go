@@ -0,0 +1,119 @@
+package dataCollection
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// switchUpstream is the Upstream whose answers can be switched between failures and successes.
+type switchUpstream struct {
+	failing int32
+	delay   time.Duration
+	calls   int32
+}
+
+func (u *switchUpstream) Post(ctx context.Context, jsonStr []byte) (int, map[string][]string, []byte, error) {
+	atomic.AddInt32(&u.calls, 1)
+	time.Sleep(u.delay)
+	if atomic.LoadInt32(&u.failing) == 1 {
+		return http.StatusServiceUnavailable, nil, nil, nil
+	}
+	return http.StatusOK, nil, []byte(`{"jsonrpc":"2.0","id":1,"result":"0x1"}`), nil
+}
+
+var testBreakerSettings = BreakerSettings{
+	Window:           time.Minute,
+	MinRequests:      4,
+	ErrorRate:        0.5,
+	SlowCall:         50 * time.Millisecond,
+	OpenTimeout:      20 * time.Millisecond,
+	HalfOpenRequests: 2,
+}
+
+func TestCircuitBreaker(t *testing.T) {
+	upstream := &switchUpstream{failing: 1}
+	b := NewCircuitBreaker("test", upstream, testBreakerSettings)
+
+	// two failures out of four requests open the circuit
+	for i := 0; i < 4; i++ {
+		atomic.StoreInt32(&upstream.failing, int32(i%2))
+		_, _, _, _ = b.Post(context.Background(), nil)
+	}
+	if b.State() != BreakerOpen {
+		t.Fatalf("Expected: %s, got : %s", BreakerOpen, b.State())
+	}
+	if _, _, _, err := b.Post(context.Background(), nil); !errors.Is(err, ErrCircuitOpen) || upstream.calls != 4 {
+		t.Errorf("Expected fail fast without calling the upstream, got %v after %d calls", err, upstream.calls)
+	}
+
+	// a failed trial opens the circuit again
+	time.Sleep(testBreakerSettings.OpenTimeout)
+	if b.State() != BreakerHalfOpen {
+		t.Fatalf("Expected: %s, got : %s", BreakerHalfOpen, b.State())
+	}
+	atomic.StoreInt32(&upstream.failing, 1)
+	_, _, _, _ = b.Post(context.Background(), nil)
+	if b.State() != BreakerOpen {
+		t.Fatalf("Expected: %s, got : %s", BreakerOpen, b.State())
+	}
+
+	// enough successful trials close it
+	time.Sleep(testBreakerSettings.OpenTimeout)
+	atomic.StoreInt32(&upstream.failing, 0)
+	for i := 0; i < testBreakerSettings.HalfOpenRequests; i++ {
+		if _, _, _, err := b.Post(context.Background(), nil); err != nil {
+			t.Errorf("unexpected error %s", err)
+		}
+	}
+	if b.State() != BreakerClosed {
+		t.Errorf("Expected: %s, got : %s", BreakerClosed, b.State())
+	}
+}
+
+func TestCircuitBreaker_halfOpenTrials(t *testing.T) {
+	b := NewCircuitBreaker("test", &switchUpstream{}, testBreakerSettings)
+	b.setState(BreakerHalfOpen, time.Now())
+	for i := 0; i < testBreakerSettings.HalfOpenRequests; i++ {
+		if !b.allow() {
+			t.Errorf("Expected trial %d to be allowed", i)
+		}
+	}
+	if b.allow() {
+		t.Error("Expected the trials beyond HalfOpenRequests to be rejected")
+	}
+}
+
+func TestCircuitBreaker_slowCalls(t *testing.T) {
+	b := NewCircuitBreaker("test", &switchUpstream{delay: 2 * testBreakerSettings.SlowCall}, testBreakerSettings)
+	for i := 0; i < testBreakerSettings.MinRequests; i++ {
+		_, _, _, _ = b.Post(context.Background(), nil)
+	}
+	if b.State() != BreakerOpen {
+		t.Errorf("Expected: %s, got : %s", BreakerOpen, b.State())
+	}
+}
+
+func TestFailoverUpstream_openCircuit(t *testing.T) {
+	working := newTestUpstream()
+	defer working.Close()
+
+	f := NewFailoverUpstream("http://127.0.0.1:0", working.URL)
+	f.endpoints[0].upstream.(*CircuitBreaker).setState(BreakerOpen, time.Now())
+	if _, err := NewClient(f).GetLastBlockNumber(context.Background()); err != nil {
+		t.Fatalf("unexpected error %s", err)
+	}
+	health := f.Health()
+	if !health[0].Healthy || health[0].Breaker != "open" || health[1].Breaker != "closed" {
+		t.Errorf("Unexpected health %+v", health)
+	}
+
+	f = NewFailoverUpstream("http://127.0.0.1:0")
+	f.endpoints[0].upstream.(*CircuitBreaker).setState(BreakerOpen, time.Now())
+	if _, err := NewClient(f).GetLastBlockNumber(context.Background()); !errors.Is(err, ErrCircuitOpen) {
+		t.Errorf("Expected: %v, got : %v", ErrCircuitOpen, err)
+	}
+}
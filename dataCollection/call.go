@@ -0,0 +1,40 @@
+package dataCollection
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/LucaPaterlini/infura/config"
+	"time"
+)
+
+// CallMsg is the message of a contract call executed by the node without creating a transaction.
+type CallMsg struct {
+	From  string    `json:"from,omitempty"`
+	To    string    `json:"to"`
+	Gas   *Uint64   `json:"gas,omitempty"`
+	Value *Quantity `json:"value,omitempty"`
+	Data  string    `json:"data,omitempty"`
+}
+
+// Call using the third party api executes the call with eth_call on the state at the given block and returns
+// the hex encoded output. The results are read from the store, when not nil, and saved into it,
+// so it must be given only for the blocks that can not change anymore.
+// If the third party is not able to provide an answer before the context is done it returns timeout error.
+func (c *Client) Call(ctx context.Context, msg CallMsg, block BlockRef, store ChunkStore) (string, error) {
+	var key string
+	if store != nil {
+		raw, _ := json.Marshal([]interface{}{msg, block})
+		key = "call:" + string(raw)
+		if value, ok := store.Get(key); ok {
+			return string(value), nil
+		}
+	}
+	var output string
+	if err := c.state(ctx, &output, "eth_call", msg, block); err != nil {
+		return "", err
+	}
+	if store != nil {
+		store.Set(key, []byte(output), time.Now().Add(config.CacheImmutableTime))
+	}
+	return output, nil
+}
@@ -0,0 +1,42 @@
+package dataCollection
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+)
+
+func TestCallMsg_MarshalJSON(t *testing.T) {
+	gas := Uint64(50000)
+	msg := CallMsg{From: "0xa9d1e08c7793af67e9d92fe308d5697fb81d3e43", To: "0xdac17f958d2ee523a2206206994597c13d831ec7", Gas: &gas, Data: "0x18160ddd"}
+	expected := `{"from":"0xa9d1e08c7793af67e9d92fe308d5697fb81d3e43","to":"0xdac17f958d2ee523a2206206994597c13d831ec7","gas":"0xc350","data":"0x18160ddd"}`
+	if got, _ := json.Marshal(msg); string(got) != expected {
+		t.Errorf("Expected: %s got : %s", expected, got)
+	}
+}
+
+func TestCall(t *testing.T) {
+	ts := newTestUpstream()
+	client := NewClient(NewHTTPUpstream(ts.URL))
+	store := &mapStore{chunks: make(map[string][]byte)}
+	msg := CallMsg{To: "0xdac17f958d2ee523a2206206994597c13d831ec7", Data: "0x18160ddd"}
+	expected := "0x00000000000000000000000000000000000000000000000000038d7ea4c68000"
+
+	output, err := client.Call(context.Background(), msg, BlockNumberRef(8368161), store)
+	if err != nil || output != expected {
+		t.Errorf("Expected: %s got : %s %v", expected, output, err)
+	}
+	if len(store.chunks) != 1 {
+		t.Errorf("Expected: 1 call stored got : %d", len(store.chunks))
+	}
+
+	// the stored output is returned without reaching the upstream anymore
+	ts.Close()
+	output, err = client.Call(context.Background(), msg, BlockNumberRef(8368161), store)
+	if err != nil || output != expected {
+		t.Errorf("Expected: %s got : %s %v", expected, output, err)
+	}
+	if _, err = client.Call(context.Background(), msg, BlockNumberRef(8368161), nil); err == nil {
+		t.Error("Expected: upstream error without the store got : nil")
+	}
+}
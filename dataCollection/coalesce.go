@@ -0,0 +1,107 @@
+package dataCollection
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+)
+
+// CoalescingStats counts the calls sent to the upstream and the ones saved by sharing an identical call in flight.
+type CoalescingStats struct {
+	UpstreamCalls uint64 `json:"upstreamCalls"`
+	SavedCalls    uint64 `json:"savedCalls"`
+}
+
+// postFunc is the signature of Upstream.Post.
+type postFunc func(ctx context.Context, jsonStr []byte) (int, map[string][]string, []byte, error)
+
+// flight is an upstream call shared by all the identical requests that arrived while it was in progress.
+type flight struct {
+	done    chan struct{}
+	waiters int
+	cancel  context.CancelFunc
+
+	statusCode int
+	header     map[string][]string
+	body       []byte
+	err        error
+}
+
+// coalescer shares a single upstream call between the identical requests in flight at the same time,
+// the shared call is abandoned only when every request waiting for it has gone away.
+type coalescer struct {
+	mtx     sync.Mutex
+	flights map[string]*flight
+	calls   uint64
+	saved   uint64
+}
+
+func newCoalescer() *coalescer {
+	return &coalescer{flights: make(map[string]*flight)}
+}
+
+// do executes post for the request, or waits for the result of the identical one already in flight.
+func (c *coalescer) do(ctx context.Context, jsonStr []byte, post postFunc) (int, map[string][]string, []byte, error) {
+	key := string(jsonStr)
+	c.mtx.Lock()
+	f, ok := c.flights[key]
+	if ok {
+		f.waiters++
+		c.mtx.Unlock()
+		atomic.AddUint64(&c.saved, 1)
+	} else {
+		// the shared call keeps the deadline of the first request but not its cancellation
+		var sharedCtx context.Context
+		var cancel context.CancelFunc
+		if deadline, ok := ctx.Deadline(); ok {
+			sharedCtx, cancel = context.WithDeadline(context.Background(), deadline)
+		} else {
+			sharedCtx, cancel = context.WithCancel(context.Background())
+		}
+		f = &flight{done: make(chan struct{}), waiters: 1, cancel: cancel}
+		c.flights[key] = f
+		c.mtx.Unlock()
+		atomic.AddUint64(&c.calls, 1)
+		go func() {
+			f.statusCode, f.header, f.body, f.err = post(sharedCtx, jsonStr)
+			c.forget(key, f)
+			cancel()
+			close(f.done)
+		}()
+	}
+
+	select {
+	case <-f.done:
+		return f.statusCode, f.header, f.body, f.err
+	case <-ctx.Done():
+		c.mtx.Lock()
+		f.waiters--
+		if f.waiters == 0 {
+			f.cancel()
+			c.forgetLocked(key, f)
+		}
+		c.mtx.Unlock()
+		return 0, nil, nil, ctx.Err()
+	}
+}
+
+// forget removes the flight from the calls in progress, unless it has already been replaced.
+func (c *coalescer) forget(key string, f *flight) {
+	c.mtx.Lock()
+	c.forgetLocked(key, f)
+	c.mtx.Unlock()
+}
+
+func (c *coalescer) forgetLocked(key string, f *flight) {
+	if c.flights[key] == f {
+		delete(c.flights, key)
+	}
+}
+
+// stats returns the counters of the coalescer.
+func (c *coalescer) stats() CoalescingStats {
+	return CoalescingStats{
+		UpstreamCalls: atomic.LoadUint64(&c.calls),
+		SavedCalls:    atomic.LoadUint64(&c.saved),
+	}
+}
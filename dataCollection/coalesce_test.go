@@ -0,0 +1,111 @@
+package dataCollection
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// gateUpstream is the Upstream that holds every request until the gate is opened.
+type gateUpstream struct {
+	gate     chan struct{}
+	calls    int32
+	canceled int32
+}
+
+func (u *gateUpstream) Post(ctx context.Context, jsonStr []byte) (int, map[string][]string, []byte, error) {
+	atomic.AddInt32(&u.calls, 1)
+	select {
+	case <-u.gate:
+		return http.StatusOK, nil, []byte(`{"jsonrpc":"2.0","id":1,"result":"0x7fb25b"}`), nil
+	case <-ctx.Done():
+		atomic.AddInt32(&u.canceled, 1)
+		return 0, nil, nil, ctx.Err()
+	}
+}
+
+// waitFor polls the condition until it is true or a second has passed.
+func waitFor(condition func() bool) bool {
+	for start := time.Now(); time.Since(start) < time.Second; time.Sleep(time.Millisecond) {
+		if condition() {
+			return true
+		}
+	}
+	return false
+}
+
+func TestClient_coalescing(t *testing.T) {
+	upstream := &gateUpstream{gate: make(chan struct{})}
+	client := NewClient(upstream)
+
+	const concurrency = 64
+	wg := sync.WaitGroup{}
+	results := make([]uint64, concurrency)
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			results[i], _ = client.GetLastBlockNumber(context.Background())
+		}(i)
+	}
+	if !waitFor(func() bool { return client.Coalescing().SavedCalls == concurrency-1 }) {
+		t.Fatalf("Expected %d coalesced calls, got %+v", concurrency-1, client.Coalescing())
+	}
+	close(upstream.gate)
+	wg.Wait()
+
+	for i, result := range results {
+		if result != 0x7fb25b {
+			t.Errorf("request %d Expected: %d, got : %d", i, 0x7fb25b, result)
+		}
+	}
+	if upstream.calls != 1 || client.Coalescing() != (CoalescingStats{UpstreamCalls: 1, SavedCalls: concurrency - 1}) {
+		t.Errorf("Expected a single upstream call, got %d calls and %+v", upstream.calls, client.Coalescing())
+	}
+
+	// once the call is completed the next request reaches the upstream again
+	if _, err := client.GetLastBlockNumber(context.Background()); err != nil || upstream.calls != 2 {
+		t.Errorf("Expected a new upstream call, got %d calls and %v", upstream.calls, err)
+	}
+}
+
+func TestClient_coalescingCancellation(t *testing.T) {
+	upstream := &gateUpstream{gate: make(chan struct{})}
+	client := NewClient(upstream)
+
+	first, cancelFirst := context.WithCancel(context.Background())
+	second, cancelSecond := context.WithCancel(context.Background())
+	errs := make(chan error, 2)
+	for _, ctx := range []context.Context{first, second} {
+		go func(ctx context.Context) {
+			_, err := client.GetLastBlockNumber(ctx)
+			errs <- err
+		}(ctx)
+	}
+	if !waitFor(func() bool { return client.Coalescing().SavedCalls == 1 }) {
+		t.Fatalf("Expected a coalesced call, got %+v", client.Coalescing())
+	}
+
+	// the shared call survives while a request is still waiting for it
+	cancelFirst()
+	if err := <-errs; !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected: %v, got : %v", context.Canceled, err)
+	}
+	time.Sleep(10 * time.Millisecond)
+	if atomic.LoadInt32(&upstream.canceled) != 0 {
+		t.Error("Expected the shared call to be still in progress")
+	}
+
+	// and is abandoned when every request has gone away
+	cancelSecond()
+	if err := <-errs; !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected: %v, got : %v", context.Canceled, err)
+	}
+	if !waitFor(func() bool { return atomic.LoadInt32(&upstream.canceled) == 1 }) {
+		t.Error("Expected the shared call to be canceled")
+	}
+}
@@ -0,0 +1,41 @@
+package dataCollection
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+// forEach calls f for each index from 0 to n-1 with up to concurrency calls in flight. The first failure cancels
+// the context of the calls still in flight and is returned, once they have all returned.
+func forEach(ctx context.Context, n, concurrency int, f func(ctx context.Context, i int) error) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	errs := make([]error, n)
+	slots := make(chan struct{}, concurrency)
+	wg := sync.WaitGroup{}
+	for i := 0; i < n && ctx.Err() == nil; i++ {
+		wg.Add(1)
+		slots <- struct{}{}
+		go func(i int) {
+			defer func() {
+				<-slots
+				wg.Done()
+			}()
+			if err := f(ctx, i); err != nil {
+				errs[i] = err
+				cancel()
+			}
+		}(i)
+	}
+	wg.Wait()
+	for _, err := range errs {
+		if err != nil && !errors.Is(err, context.Canceled) {
+			return err
+		}
+	}
+	if err := ctx.Err(); err != nil {
+		return transportError(err)
+	}
+	return nil
+}
@@ -0,0 +1,51 @@
+package dataCollection
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestForEach(t *testing.T) {
+	var inFlight, maxInFlight int64
+	done := make([]bool, 20)
+	err := forEach(context.Background(), len(done), 3, func(ctx context.Context, i int) error {
+		n := atomic.AddInt64(&inFlight, 1)
+		for {
+			max := atomic.LoadInt64(&maxInFlight)
+			if n <= max || atomic.CompareAndSwapInt64(&maxInFlight, max, n) {
+				break
+			}
+		}
+		time.Sleep(time.Millisecond)
+		atomic.AddInt64(&inFlight, -1)
+		done[i] = true
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if maxInFlight > 3 {
+		t.Errorf("Expected: at most 3 calls in flight got : %d", maxInFlight)
+	}
+	for i, ok := range done {
+		if !ok {
+			t.Errorf("Expected: the call %d got : none", i)
+		}
+	}
+
+	// the first failure cancels the calls in flight and is returned
+	failure := errors.New("failure")
+	err = forEach(context.Background(), 10, 2, func(ctx context.Context, i int) error {
+		if i == 0 {
+			return failure
+		}
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	if !errors.Is(err, failure) {
+		t.Errorf("Expected: %v got : %v", failure, err)
+	}
+}
@@ -1,67 +1,248 @@
-// Package dataCollection provides a suite of functions to access the official INFURA API
+// Package dataCollection provides a suite of functions to access an Ethereum JSON-RPC upstream, by default the official INFURA API
 package dataCollection
 
 import (
-	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"github.com/LucaPaterlini/infura/config"
-	"io/ioutil"
 	"net/http"
-	"strconv"
 	"time"
 )
 
-// apiCallPOST call the third party api with a timeout, and returns the content of the http response.
-func apiCallPOST(jsonStr []byte, requestTimeout time.Duration) (statusCode int, header map[string][]string, body []byte, err error) {
-	url := config.FullMainNetPath
+// Client collects the data from the Upstream it has been configured with,
+// retrying the transient failures according to its RetryPolicy
+// and sharing a single upstream call between the identical requests in flight.
+type Client struct {
+	upstream Upstream
+	flights  *coalescer
+	Retry    RetryPolicy
+}
+
+// NewClient returns a Client that sends all its requests to the given upstream using the DefaultRetryPolicy.
+func NewClient(upstream Upstream) *Client {
+	return &Client{upstream: upstream, flights: newCoalescer(), Retry: DefaultRetryPolicy}
+}
+
+// Coalescing returns how many upstream calls have been sent and how many have been saved by coalescing.
+func (c *Client) Coalescing() CoalescingStats {
+	return c.flights.stats()
+}
+
+// Health returns the health of the upstream endpoints, or nil if the upstream is not able to report it.
+func (c *Client) Health() []UpstreamHealth {
+	if reporter, ok := c.upstream.(HealthReporter); ok {
+		return reporter.Health()
+	}
+	return nil
+}
+
+// request is the envelope of a JSON-RPC request.
+type request struct {
+	JSONRPC string        `json:"jsonrpc"`
+	Method  string        `json:"method"`
+	Params  []interface{} `json:"params"`
+	ID      int           `json:"id"`
+}
 
-	client := &http.Client{Timeout: requestTimeout}
+// apiCallPOST call the upstream bound to the context, and returns the content of the http response,
+// the identical calls in flight at the same time share the same response.
+func (c *Client) apiCallPOST(ctx context.Context, jsonStr []byte) (statusCode int, header map[string][]string, body []byte, err error) {
+	return c.flights.do(ctx, jsonStr, c.upstream.Post)
+}
 
-	var resp *http.Response
-	resp, err = client.Post(url, "application/json", bytes.NewBuffer(jsonStr))
+// call executes the JSON-RPC method with the given params and decodes its result into result,
+// it reports false when the upstream answered with a null result.
+// The errors returned wrap one of the kinds of errors defined by the package.
+func (c *Client) call(ctx context.Context, result interface{}, method string, params ...interface{}) (bool, error) {
+	if params == nil {
+		params = []interface{}{}
+	}
+	jsonStr, err := json.Marshal(request{JSONRPC: "2.0", Method: method, Params: params, ID: 1})
 	if err != nil {
-		return
+		return false, err
 	}
-	body, _ = ioutil.ReadAll(resp.Body)
+	var found bool
+	err = c.send(ctx, jsonStr, func(statusCode int, body []byte, err error) error {
+		found, err = decode(statusCode, body, err, result)
+		return err
+	})
+	return found, err
+}
 
-	_ = resp.Body.Close()
+// send posts the request to the upstream and decodes its response with decode,
+// retrying the transient failures according to the RetryPolicy of the client.
+func (c *Client) send(ctx context.Context, jsonStr []byte, decode func(statusCode int, body []byte, err error) error) error {
+	for attempt := 1; ; attempt++ {
+		statusCode, header, body, err := c.apiCallPOST(ctx, jsonStr)
+		err = decode(statusCode, body, err)
+		if attempt >= c.Retry.MaxAttempts || !c.Retry.retryable(statusCode, err) {
+			return err
+		}
+		// give up when the next attempt would not fit in the deadline of the request
+		wait := c.Retry.backoff(attempt, header)
+		if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) <= wait {
+			return err
+		}
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		case <-timer.C:
+		}
+	}
+}
 
-	statusCode = resp.StatusCode
-	header = resp.Header
-	return
+// decode decodes the response of the upstream into result, wrapping the errors with their kind.
+func decode(statusCode int, body []byte, err error, result interface{}) (bool, error) {
+	if err != nil {
+		return false, transportError(err)
+	}
+	if statusCode == http.StatusTooManyRequests {
+		return false, statusError(statusCode, nil)
+	}
+	found, err := Decode(body, result)
+	var rpcErr *RPCError
+	if err != nil && !errors.As(err, &rpcErr) {
+		return false, statusError(statusCode, err)
+	}
+	return found, err
 }
 
-// GetBlock using the third party api gets the data of the requested block,
-// if the third party is not able to provide an answer within the requested timeout it returns timeout error.
-func GetBlock(blockNumber uint64, requestTimeout time.Duration) (int, map[string][]string, []byte, error) {
-	var jsonStr = []byte(fmt.Sprintf(`{"jsonrpc":"2.0","method":"eth_getBlockByNumber",
-		"params": ["0x%x",false],"id":1}`, blockNumber))
-	return apiCallPOST(jsonStr, requestTimeout)
+// GetBlock using the third party api gets the data of the requested block, nil if the block has not been mined yet,
+// if the third party is not able to provide an answer before the context is done it returns timeout error.
+func (c *Client) GetBlock(ctx context.Context, blockNumber uint64) (*Block, error) {
+	block := new(Block)
+	if found, err := c.call(ctx, block, "eth_getBlockByNumber", Uint64(blockNumber), false); !found {
+		return nil, err
+	}
+	return block, nil
 }
 
-// GetTransaction using the third party api gets the data of the requested transaction,
-// if the third party is not able to provide an answer within the requested timeout it returns timeout error.
-func GetTransaction(blockNumber, index uint64, requestTimeout time.Duration) (int, map[string][]string, []byte, error) {
-	var jsonStr = []byte(fmt.Sprintf(`{"jsonrpc":"2.0","method":"eth_getTransactionByBlockNumberAndIndex",
-		"params": ["0x%x","0x0"],"id":%d}`, blockNumber, index))
-	return apiCallPOST(jsonStr, requestTimeout)
+// GetFullBlock using the third party api gets the data of the requested block with the full objects of its transactions,
+// nil if it does not exist, if the third party is not able to provide an answer before the context is done it returns timeout error.
+func (c *Client) GetFullBlock(ctx context.Context, blockNumber uint64) (*Block, error) {
+	block := new(Block)
+	if found, err := c.call(ctx, block, "eth_getBlockByNumber", Uint64(blockNumber), true); !found {
+		return nil, err
+	}
+	return block, nil
 }
 
-// GetLastBlockNumber using the third party api gets the last block,
-// if the third party is not able to provide an answer within the requested timeout it returns timeout error.
-func GetLastBlockNumber(requestTimeout time.Duration) (lastBlock uint64, err error) {
-	var jsonStr = []byte(fmt.Sprintf(`{"jsonrpc":"2.0","method":"eth_blockNumber","params": [],"id":1}`))
-	var body []byte
-	_, _, body, err = apiCallPOST(jsonStr, requestTimeout)
+// GetBlockByTag using the third party api gets the data of the block the tag refers to, with the full transactions
+// when requested, nil if there is none, such as the finalized block before the merge,
+// if the third party is not able to provide an answer before the context is done it returns timeout error.
+func (c *Client) GetBlockByTag(ctx context.Context, tag string, full bool) (*Block, error) {
+	block := new(Block)
+	if found, err := c.call(ctx, block, "eth_getBlockByNumber", tag, full); !found {
+		return nil, err
+	}
+	return block, nil
+}
+
+// GetBlockByHash using the third party api gets the data of the block with the given hash, nil if it is not known,
+// if the third party is not able to provide an answer before the context is done it returns timeout error.
+func (c *Client) GetBlockByHash(ctx context.Context, hash string) (*Block, error) {
+	block := new(Block)
+	if found, err := c.call(ctx, block, "eth_getBlockByHash", hash, false); !found {
+		return nil, err
+	}
+	return block, nil
+}
+
+// GetTransaction using the third party api gets the data of the requested transaction, nil if it does not exist,
+// if the third party is not able to provide an answer before the context is done it returns timeout error.
+func (c *Client) GetTransaction(ctx context.Context, blockNumber, index uint64) (*Transaction, error) {
+	tx := new(Transaction)
+	if found, err := c.call(ctx, tx, "eth_getTransactionByBlockNumberAndIndex", Uint64(blockNumber), Uint64(index)); !found {
+		return nil, err
+	}
+	return tx, nil
+}
+
+// GetTransactionByTag using the third party api gets the data of the transaction at the index of the block the tag refers to,
+// nil if it does not exist, if the third party is not able to provide an answer before the context is done it returns timeout error.
+func (c *Client) GetTransactionByTag(ctx context.Context, tag string, index uint64) (*Transaction, error) {
+	tx := new(Transaction)
+	if found, err := c.call(ctx, tx, "eth_getTransactionByBlockNumberAndIndex", tag, Uint64(index)); !found {
+		return nil, err
+	}
+	return tx, nil
+}
+
+// GetTransactionByHash using the third party api gets the data of the transaction with the given hash,
+// nil if it is not known, if the third party is not able to provide an answer before the context is done it returns timeout error.
+func (c *Client) GetTransactionByHash(ctx context.Context, hash string) (*Transaction, error) {
+	tx := new(Transaction)
+	if found, err := c.call(ctx, tx, "eth_getTransactionByHash", hash); !found {
+		return nil, err
+	}
+	return tx, nil
+}
+
+// GetTransactionReceipt using the third party api gets the receipt of the transaction with the given hash,
+// nil if the transaction is not known or not mined yet,
+// if the third party is not able to provide an answer before the context is done it returns timeout error.
+func (c *Client) GetTransactionReceipt(ctx context.Context, hash string) (*Receipt, error) {
+	receipt := new(Receipt)
+	if found, err := c.call(ctx, receipt, "eth_getTransactionReceipt", hash); !found {
+		return nil, err
+	}
+	return receipt, nil
+}
+
+// GetBlockReceipts using the third party api gets the receipts of all the transactions of the requested block,
+// nil if the block has not been mined yet, when the third party does not support eth_getBlockReceipts
+// the receipts are requested one transaction at a time.
+// If the third party is not able to provide an answer before the context is done it returns timeout error.
+func (c *Client) GetBlockReceipts(ctx context.Context, blockNumber uint64) ([]Receipt, error) {
+	var receipts []Receipt
+	found, err := c.call(ctx, &receipts, "eth_getBlockReceipts", Uint64(blockNumber))
+	var rpcErr *RPCError
+	if errors.As(err, &rpcErr) && rpcErr.Code == codeMethodNotFound {
+		return c.getBlockReceiptsByTx(ctx, blockNumber)
+	}
+	if !found {
+		return nil, err
+	}
+	return receipts, nil
+}
+
+// getBlockReceiptsByTx gets the receipts of all the transactions of the requested block one by one,
+// with up to config.ReceiptsConcurrency requests in flight, nil if the block has not been mined yet.
+func (c *Client) getBlockReceiptsByTx(ctx context.Context, blockNumber uint64) ([]Receipt, error) {
+	block, err := c.GetBlock(ctx, blockNumber)
+	if block == nil {
+		return nil, err
+	}
+	hashes := block.Transactions.Hashes
+	receipts := make([]Receipt, len(hashes))
+	err = forEach(ctx, len(hashes), config.ReceiptsConcurrency, func(ctx context.Context, i int) error {
+		receipt, err := c.GetTransactionReceipt(ctx, hashes[i])
+		if err == nil && receipt == nil {
+			err = fmt.Errorf("%w: receipt of the transaction %s", ErrNotFound, hashes[i])
+		}
+		if err != nil {
+			return err
+		}
+		receipts[i] = *receipt
+		return nil
+	})
 	if err != nil {
-		return
+		return nil, err
 	}
-	type Response struct {
-		Result string
+	return receipts, nil
+}
+
+// GetLastBlockNumber using the third party api gets the last block,
+// if the third party is not able to provide an answer before the context is done it returns timeout error.
+func (c *Client) GetLastBlockNumber(ctx context.Context) (lastBlock uint64, err error) {
+	var result Uint64
+	found, err := c.call(ctx, &result, "eth_blockNumber")
+	if err == nil && !found {
+		err = fmt.Errorf("eth_blockNumber returned no block")
 	}
-	var resp Response
-	_ = json.Unmarshal(body, &resp)
-	lastBlock, err = strconv.ParseUint(resp.Result[2:], 16, 64)
-	return
+	return uint64(result), err
 }
@@ -1,17 +1,27 @@
 package dataCollection
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"github.com/go-test/deep"
+	"net/http"
+	"net/http/httptest"
 	"testing"
 )
 
 func TestGetBlock(t *testing.T) {
+	ts := newTestUpstream()
+	defer ts.Close()
+	client := NewClient(NewHTTPUpstream(ts.URL))
 	for _, tc := range testCasesGetBlock {
 		description := fmt.Sprintf("Test:%s, GetBlock(%d,%d), ",
 			tc.description, tc.blockNumber, tc.requestTimeout)
 
-		gotStatus, gotHeader, gotBody, gotErr := GetBlock(tc.blockNumber, tc.requestTimeout)
+		ctx, cancel := context.WithTimeout(context.Background(), tc.requestTimeout)
+		gotBlock, gotErr := client.GetBlock(ctx, tc.blockNumber)
+		cancel()
 
 		switch {
 		case tc.expectedError != nil && gotErr == nil:
@@ -19,22 +29,47 @@ func TestGetBlock(t *testing.T) {
 		case tc.expectedError == nil && gotErr != nil:
 			t.Errorf(description+"unexpected error \n%s", gotErr.Error())
 		default:
-			gotResponse := expectedRetrieveTransaction{Status: gotStatus, Header: gotHeader, Body: gotBody}
-			// removing delete because cannot be tested against live api
-			delete(gotHeader, "Date")
-			if diffList := deep.Equal(tc.expected, gotResponse); len(diffList) > 0 {
+			gotResult, _ := json.Marshal(gotBlock)
+			if diffList := deep.Equal(tc.expectedResult, string(gotResult)); len(diffList) > 0 {
 				t.Errorf(description+"\nDiff    : %v\n", diffList)
 			}
 		}
 	}
 }
 
+func TestGetFullBlock(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Params json.RawMessage
+		}
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		if string(req.Params) != `["0x7fb021",true]` {
+			_, _ = w.Write([]byte(`{"jsonrpc":"2.0","id":1,"result":null}`))
+			return
+		}
+		_, _ = fmt.Fprintf(w, `{"jsonrpc":"2.0","id":1,"result":{"number":"0x7fb021","transactions":[%s]}}`, resultOf(recordedTransaction8368161))
+	}))
+	defer ts.Close()
+	block, err := NewClient(NewHTTPUpstream(ts.URL)).GetFullBlock(context.Background(), 8368161)
+	if err != nil || block == nil {
+		t.Fatalf("unexpected result %v %v", block, err)
+	}
+	if len(block.Transactions.Full) != 1 || block.Transactions.Full[0].Hash != "0x7adfcf6e2947590cb88763af73c923f1d1832c07830a8891199832e506103430" {
+		t.Errorf("Expected: the full transaction got : %+v", block.Transactions)
+	}
+}
+
 func TestGetTransaction(t *testing.T) {
+	ts := newTestUpstream()
+	defer ts.Close()
+	client := NewClient(NewHTTPUpstream(ts.URL))
 	for _, tc := range testCasesGetTransaction {
 		description := fmt.Sprintf("Test:%s, GetTransaction(%d,%d,%d), ",
 			tc.description, tc.blockNumber, tc.index, tc.requestTimeout)
 
-		gotStatus, gotHeader, gotBody, gotErr := GetTransaction(tc.blockNumber, tc.index, tc.requestTimeout)
+		ctx, cancel := context.WithTimeout(context.Background(), tc.requestTimeout)
+		gotTx, gotErr := client.GetTransaction(ctx, tc.blockNumber, tc.index)
+		cancel()
 
 		switch {
 		case tc.expectedError != nil && gotErr == nil:
@@ -43,10 +78,8 @@ func TestGetTransaction(t *testing.T) {
 			t.Errorf(description+"unexpected error \n%s", gotErr.Error())
 
 		default:
-			gotResponse := expectedRetrieveTransaction{Status: gotStatus, Header: gotHeader, Body: gotBody}
-			// removing delete because cannot be tested against live apiz
-			delete(gotHeader, "Date")
-			if diffList := deep.Equal(tc.expected, gotResponse); len(diffList) > 0 {
+			gotResult, _ := json.Marshal(gotTx)
+			if diffList := deep.Equal(tc.expectedResult, string(gotResult)); len(diffList) > 0 {
 				t.Errorf(description+"\nDiff    : %v\n", diffList)
 			}
 		}
@@ -54,11 +87,16 @@ func TestGetTransaction(t *testing.T) {
 }
 
 func TestGetLastBlockNumber(t *testing.T) {
+	ts := newTestUpstream()
+	defer ts.Close()
+	client := NewClient(NewHTTPUpstream(ts.URL))
 	for _, tc := range testCasesGetLastBlock {
 		description := fmt.Sprintf("Test:%s, GetLastBlockNumber(%d), ",
 			tc.description, tc.requestTimeout)
 
-		gotLastBlock, gotErr := GetLastBlockNumber(tc.requestTimeout)
+		ctx, cancel := context.WithTimeout(context.Background(), tc.requestTimeout)
+		gotLastBlock, gotErr := client.GetLastBlockNumber(ctx)
+		cancel()
 
 		switch {
 		case tc.expectedError != nil && gotErr == nil:
@@ -72,3 +110,79 @@ func TestGetLastBlockNumber(t *testing.T) {
 		}
 	}
 }
+
+func TestGetTransactionReceipt(t *testing.T) {
+	ts := newTestUpstream()
+	defer ts.Close()
+	client := NewClient(NewHTTPUpstream(ts.URL))
+	for _, tc := range testCasesGetTransactionReceipt {
+		receipt, err := client.GetTransactionReceipt(context.Background(), tc.hash)
+		if err != nil {
+			t.Errorf("Test:%s unexpected error \n%s", tc.description, err)
+			continue
+		}
+		gotResult, _ := json.Marshal(receipt)
+		if diffList := deep.Equal(tc.expectedResult, string(gotResult)); len(diffList) > 0 {
+			t.Errorf("Test:%s\nDiff    : %v\n", tc.description, diffList)
+		}
+	}
+}
+
+func TestGetBlockReceipts(t *testing.T) {
+	ts := newTestUpstream()
+	defer ts.Close()
+	client := NewClient(NewHTTPUpstream(ts.URL))
+	for _, tc := range testCasesGetBlockReceipts {
+		receipts, err := client.GetBlockReceipts(context.Background(), tc.blockNumber)
+		if err != nil {
+			t.Errorf("Test:%s unexpected error \n%s", tc.description, err)
+			continue
+		}
+		gotResult, _ := json.Marshal(receipts)
+		if diffList := deep.Equal(tc.expectedResult, string(gotResult)); len(diffList) > 0 {
+			t.Errorf("Test:%s\nDiff    : %v\n", tc.description, diffList)
+		}
+	}
+}
+
+// newNoBlockReceiptsUpstream returns the endpoint that does not support eth_getBlockReceipts,
+// replying the receipt of every transaction but the missing one.
+func newNoBlockReceiptsUpstream(missing string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req request
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		switch {
+		case req.Method == "eth_getBlockReceipts":
+			_, _ = w.Write([]byte(`{"jsonrpc":"2.0","id":1,"error":{"code":-32601,"message":"the method eth_getBlockReceipts does not exist/is not available"}}`))
+		case req.Method == "eth_getBlockByNumber":
+			_, _ = w.Write([]byte(`{"jsonrpc":"2.0","id":1,"result":{"number":"0x7fb021","transactions":["0x01","0x02","0x03"]}}`))
+		case req.Method == "eth_getTransactionReceipt" && req.Params[0] != missing:
+			_, _ = fmt.Fprintf(w, `{"jsonrpc":"2.0","id":1,"result":{"blockNumber":"0x7fb021","transactionHash":"%s"}}`, req.Params[0])
+		default:
+			_, _ = w.Write([]byte(`{"jsonrpc":"2.0","id":1,"result":null}`))
+		}
+	}))
+}
+
+func TestGetBlockReceipts_fallback(t *testing.T) {
+	ts := newNoBlockReceiptsUpstream("")
+	defer ts.Close()
+	receipts, err := NewClient(NewHTTPUpstream(ts.URL)).GetBlockReceipts(context.Background(), 8368161)
+	if err != nil {
+		t.Fatalf("unexpected error %s", err)
+	}
+	var got []string
+	for _, receipt := range receipts {
+		got = append(got, receipt.TransactionHash)
+	}
+	// the receipts are in the order of the transactions of the block
+	if diff := deep.Equal([]string{"0x01", "0x02", "0x03"}, got); diff != nil {
+		t.Error(diff)
+	}
+
+	missing := newNoBlockReceiptsUpstream("0x02")
+	defer missing.Close()
+	if _, err := NewClient(NewHTTPUpstream(missing.URL)).GetBlockReceipts(context.Background(), 8368161); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected: %v got : %v", ErrNotFound, err)
+	}
+}
@@ -0,0 +1,104 @@
+package dataCollection
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+)
+
+// The kinds of errors returned by the upstream, the errors returned by the Client wrap one of them
+// so that they can be checked with errors.Is.
+var (
+	ErrRateLimited      = errors.New("upstream rate limit exceeded")
+	ErrInvalidParams    = errors.New("invalid params")
+	ErrNotFound         = errors.New("resource not found")
+	ErrUpstreamInternal = errors.New("upstream internal error")
+	ErrTimeout          = errors.New("upstream timeout")
+)
+
+// The JSON-RPC error codes, as defined by the JSON-RPC 2.0 specification and EIP-1474.
+const (
+	codeExecutionReverted = 3
+	codeInvalidRequest    = -32600
+	codeMethodNotFound    = -32601
+	codeInvalidParams     = -32602
+	codeInvalidInput      = -32000
+	codeResourceNotFound  = -32001
+	codeLimitExceeded     = -32005
+)
+
+// invalidInputMessages are the parts of the messages of the codeInvalidInput errors caused by the request itself,
+// as that code is also used by geth and Infura for the conditions of the node, such as "header not found"
+// or "missing trie node", that another attempt or another upstream can answer.
+var invalidInputMessages = []string{
+	"invalid", "range", "results", "size", "too large", "exceed", "execution reverted", "insufficient funds",
+	"nonce too", "gas required", "intrinsic gas", "already known", "underpriced",
+}
+
+// RPCError is the error object of a JSON-RPC response.
+type RPCError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *RPCError) Error() string {
+	return fmt.Sprintf("%s: %s (code %d)", e.Unwrap(), e.Message, e.Code)
+}
+
+// Unwrap returns the kind of the error according to its code.
+func (e *RPCError) Unwrap() error {
+	switch {
+	case e.Code == codeLimitExceeded && strings.Contains(e.Message, "results"):
+		// the query returned too many results, asking again would not help
+		return ErrInvalidParams
+	case e.Code == codeLimitExceeded:
+		return ErrRateLimited
+	case e.Code == codeInvalidInput && invalidInput(e.Message):
+		return ErrInvalidParams
+	case e.Code == codeInvalidRequest || e.Code == codeMethodNotFound || e.Code == codeInvalidParams || e.Code == codeExecutionReverted:
+		return ErrInvalidParams
+	case e.Code == codeResourceNotFound:
+		return ErrNotFound
+	default:
+		return ErrUpstreamInternal
+	}
+}
+
+// invalidInput reports if the message of a codeInvalidInput error blames the request.
+func invalidInput(message string) bool {
+	message = strings.ToLower(message)
+	for _, part := range invalidInputMessages {
+		if strings.Contains(message, part) {
+			return true
+		}
+	}
+	return false
+}
+
+// transportError wraps the error returned while reaching the upstream with its kind.
+func transportError(err error) error {
+	// the requests canceled by the caller are not a failure of the upstream
+	if errors.Is(err, ErrCircuitOpen) || errors.Is(err, context.Canceled) {
+		return err
+	}
+	var netErr net.Error
+	if errors.Is(err, context.DeadlineExceeded) || errors.As(err, &netErr) && netErr.Timeout() {
+		return fmt.Errorf("%w: %s", ErrTimeout, err)
+	}
+	return fmt.Errorf("%w: %s", ErrUpstreamInternal, err)
+}
+
+// statusError returns the error corresponding to the http status of a response that was not decodable.
+func statusError(statusCode int, err error) error {
+	switch {
+	case statusCode == http.StatusTooManyRequests:
+		return fmt.Errorf("%w: status code %d", ErrRateLimited, statusCode)
+	case statusCode >= http.StatusInternalServerError:
+		return fmt.Errorf("%w: status code %d", ErrUpstreamInternal, statusCode)
+	default:
+		return fmt.Errorf("%w: %s", ErrUpstreamInternal, err)
+	}
+}
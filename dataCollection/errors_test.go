@@ -0,0 +1,90 @@
+package dataCollection
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// newStaticUpstream starts a local upstream that answers every request with the given status and body.
+func newStaticUpstream(statusCode int, body string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(statusCode)
+		_, _ = w.Write([]byte(body))
+	}))
+}
+
+func TestClient_errors(t *testing.T) {
+	for _, tc := range []struct {
+		statusCode    int
+		body          string
+		expectedError error
+		description   string
+	}{
+		{http.StatusOK, `{"jsonrpc":"2.0","id":1,"error":{"code":-32005,"message":"daily request count exceeded"}}`, ErrRateLimited, "rate limit error object"},
+		{http.StatusTooManyRequests, `too many requests`, ErrRateLimited, "rate limit status"},
+		{http.StatusOK, `{"jsonrpc":"2.0","id":1,"error":{"code":-32602,"message":"invalid argument 0"}}`, ErrInvalidParams, "invalid params error object"},
+		{http.StatusOK, `{"jsonrpc":"2.0","id":1,"error":{"code":-32601,"message":"method not found"}}`, ErrInvalidParams, "method not found error object"},
+		{http.StatusOK, `{"jsonrpc":"2.0","id":1,"error":{"code":-32001,"message":"resource not found"}}`, ErrNotFound, "resource not found error object"},
+		{http.StatusOK, `{"jsonrpc":"2.0","id":1,"error":{"code":-32603,"message":"internal error"}}`, ErrUpstreamInternal, "internal error object"},
+		{http.StatusBadGateway, `<html>bad gateway</html>`, ErrUpstreamInternal, "not decodable 5xx"},
+		{http.StatusOK, `<html>maintenance</html>`, ErrUpstreamInternal, "not decodable body"},
+	} {
+		ts := newStaticUpstream(tc.statusCode, tc.body)
+		client := NewClient(NewHTTPUpstream(ts.URL))
+		client.Retry.MaxAttempts = 1
+		_, err := client.GetBlock(context.Background(), 1)
+		ts.Close()
+		if !errors.Is(err, tc.expectedError) {
+			t.Errorf("Test:%s, Expected: %v, got : %v", tc.description, tc.expectedError, err)
+		}
+	}
+
+	_, err := NewClient(NewHTTPUpstream("http://127.0.0.1:0")).GetBlock(context.Background(), 1)
+	if !errors.Is(err, ErrUpstreamInternal) {
+		t.Errorf("Expected: %v, got : %v", ErrUpstreamInternal, err)
+	}
+	ts := newTestUpstream()
+	defer ts.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), time.Nanosecond)
+	defer cancel()
+	_, err = NewClient(NewHTTPUpstream(ts.URL)).GetBlock(ctx, 1)
+	if !errors.Is(err, ErrTimeout) {
+		t.Errorf("Expected: %v, got : %v", ErrTimeout, err)
+	}
+}
+
+func TestRPCError_Error(t *testing.T) {
+	err := &RPCError{Code: -32005, Message: "daily request count exceeded"}
+	expected := "upstream rate limit exceeded: daily request count exceeded (code -32005)"
+	if err.Error() != expected {
+		t.Errorf("Expected: %s, got : %s", expected, err.Error())
+	}
+}
+
+func TestRPCError_Unwrap(t *testing.T) {
+	for _, tc := range []struct {
+		err      *RPCError
+		expected error
+	}{
+		{err: &RPCError{Code: -32005, Message: "daily request count exceeded"}, expected: ErrRateLimited},
+		{err: &RPCError{Code: -32005, Message: "query returned more than 10000 results"}, expected: ErrInvalidParams},
+		{err: &RPCError{Code: -32601, Message: "the method eth_foo does not exist/is not available"}, expected: ErrInvalidParams},
+		{err: &RPCError{Code: 3, Message: "execution reverted: ERC20: transfer amount exceeds balance"}, expected: ErrInvalidParams},
+		{err: &RPCError{Code: -32000, Message: "query returned more than 10000 results"}, expected: ErrInvalidParams},
+		{err: &RPCError{Code: -32000, Message: "block range is too wide"}, expected: ErrInvalidParams},
+		{err: &RPCError{Code: -32000, Message: "invalid argument 0: hex string without 0x prefix"}, expected: ErrInvalidParams},
+		{err: &RPCError{Code: -32000, Message: "header not found"}, expected: ErrUpstreamInternal},
+		{err: &RPCError{Code: -32000, Message: "missing trie node 1f2a (path )"}, expected: ErrUpstreamInternal},
+		{err: &RPCError{Code: -32000, Message: "request timed out"}, expected: ErrUpstreamInternal},
+		{err: &RPCError{Code: -32001, Message: "block not found"}, expected: ErrNotFound},
+		{err: &RPCError{Code: -32603, Message: "internal error"}, expected: ErrUpstreamInternal},
+	} {
+		if !errors.Is(tc.err, tc.expected) {
+			t.Errorf("Expected: %v, got : %v", tc.expected, tc.err.Unwrap())
+		}
+	}
+}
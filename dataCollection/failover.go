@@ -0,0 +1,190 @@
+package dataCollection
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// UpstreamHealth describes the state of an upstream endpoint as seen by the health checks and the last requests.
+type UpstreamHealth struct {
+	URL       string    `json:"url"`
+	Healthy   bool      `json:"healthy"`
+	LastBlock uint64    `json:"lastBlock"`
+	LastCheck time.Time `json:"lastCheck"`
+	LastError string    `json:"lastError,omitempty"`
+	Breaker   string    `json:"breaker,omitempty"`
+}
+
+// HealthReporter is implemented by the upstreams able to report the health of their endpoints.
+type HealthReporter interface {
+	Health() []UpstreamHealth
+}
+
+type endpoint struct {
+	upstream Upstream
+	mtx      sync.RWMutex
+	health   UpstreamHealth
+}
+
+func (e *endpoint) healthy() bool {
+	e.mtx.RLock()
+	defer e.mtx.RUnlock()
+	return e.health.Healthy
+}
+
+func (e *endpoint) markHealthy(lastBlock uint64) {
+	e.mtx.Lock()
+	e.health.Healthy = true
+	e.health.LastBlock = lastBlock
+	e.health.LastCheck = time.Now()
+	e.health.LastError = ""
+	e.mtx.Unlock()
+}
+
+func (e *endpoint) markUnhealthy(err error) {
+	e.mtx.Lock()
+	if e.health.Healthy {
+		log.Printf("upstream %s out of rotation: %s", e.health.URL, err)
+	}
+	e.health.Healthy = false
+	e.health.LastCheck = time.Now()
+	e.health.LastError = err.Error()
+	e.mtx.Unlock()
+}
+
+// FailoverUpstream is the Upstream that sends the requests to an ordered list of endpoints,
+// it moves to the next endpoint when one times out, answers with a 5xx status or is marked unhealthy by the health checks.
+type FailoverUpstream struct {
+	// AttemptTimeout is the time given to each endpoint, by default an equal share of what is left of the deadline
+	// of the request among the endpoints not tried yet
+	AttemptTimeout time.Duration
+	endpoints      []*endpoint
+}
+
+// NewFailoverUpstream returns the FailoverUpstream for the given endpoints urls, in order of preference,
+// each endpoint is protected by its own circuit breaker with the DefaultBreakerSettings.
+func NewFailoverUpstream(urls ...string) *FailoverUpstream {
+	upstreams := make([]Upstream, len(urls))
+	for i, url := range urls {
+		upstreams[i] = NewCircuitBreaker(url, NewHTTPUpstream(url), DefaultBreakerSettings)
+	}
+	return newFailoverUpstream(urls, upstreams)
+}
+
+func newFailoverUpstream(urls []string, upstreams []Upstream) *FailoverUpstream {
+	f := &FailoverUpstream{endpoints: make([]*endpoint, len(upstreams))}
+	for i := range upstreams {
+		// every endpoint is in rotation until proven otherwise
+		f.endpoints[i] = &endpoint{upstream: upstreams[i], health: UpstreamHealth{URL: urls[i], Healthy: true}}
+	}
+	return f
+}
+
+// Post sends the request to the first healthy endpoint able to answer, if every endpoint is unhealthy
+// they are all tried anyway in order, returning the response of the last one.
+// Each endpoint has its own timeout, so that a slow one is marked unhealthy leaving time to the next ones.
+// The endpoints with an open circuit are skipped without being marked unhealthy,
+// and once the context is done no other endpoint is tried.
+func (f *FailoverUpstream) Post(ctx context.Context, jsonStr []byte) (statusCode int, header map[string][]string, body []byte, err error) {
+	if len(f.endpoints) == 0 {
+		err = fmt.Errorf("no upstream configured")
+		return
+	}
+	tried := 0
+	for _, onlyHealthy := range []bool{true, false} {
+		for _, e := range f.endpoints {
+			if e.healthy() != onlyHealthy {
+				continue
+			}
+			attemptCtx, cancel := f.attemptContext(ctx, len(f.endpoints)-tried)
+			tried++
+			statusCode, header, body, err = e.upstream.Post(attemptCtx, jsonStr)
+			cancel()
+			switch {
+			case ctx.Err() != nil:
+				return
+			case errors.Is(err, ErrCircuitOpen):
+				continue
+			case err != nil:
+				e.markUnhealthy(err)
+			case statusCode >= http.StatusInternalServerError:
+				e.markUnhealthy(fmt.Errorf("status code %d", statusCode))
+			default:
+				return
+			}
+		}
+	}
+	return
+}
+
+// attemptContext returns the context of the attempt on an endpoint, with left endpoints still to try,
+// that times out after AttemptTimeout or, when it is not set, after its share of what is left of the deadline.
+func (f *FailoverUpstream) attemptContext(ctx context.Context, left int) (context.Context, context.CancelFunc) {
+	timeout := f.AttemptTimeout
+	if deadline, ok := ctx.Deadline(); ok && timeout == 0 && left > 1 {
+		timeout = time.Until(deadline) / time.Duration(left)
+	}
+	if timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, timeout)
+}
+
+// HealthCheck probes every endpoint with eth_blockNumber every freq interval, with a set timeout,
+// taking out of rotation the ones that fail and putting back the ones that recover, until the context is done.
+func (f *FailoverUpstream) HealthCheck(ctx context.Context, freq, timeout time.Duration) {
+	f.probe(ctx, timeout)
+	ticker := time.NewTicker(freq)
+	go func() {
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				f.probe(ctx, timeout)
+			}
+		}
+	}()
+}
+
+func (f *FailoverUpstream) probe(ctx context.Context, timeout time.Duration) {
+	wg := sync.WaitGroup{}
+	for _, e := range f.endpoints {
+		wg.Add(1)
+		go func(e *endpoint) {
+			defer wg.Done()
+			probeCtx, cancel := context.WithTimeout(ctx, timeout)
+			defer cancel()
+			lastBlock, err := NewClient(e.upstream).GetLastBlockNumber(probeCtx)
+			if ctx.Err() != nil {
+				return
+			}
+			if err != nil {
+				e.markUnhealthy(err)
+				return
+			}
+			e.markHealthy(lastBlock)
+		}(e)
+	}
+	wg.Wait()
+}
+
+// Health returns the current health of every endpoint, in order of preference.
+func (f *FailoverUpstream) Health() []UpstreamHealth {
+	health := make([]UpstreamHealth, len(f.endpoints))
+	for i, e := range f.endpoints {
+		e.mtx.RLock()
+		health[i] = e.health
+		e.mtx.RUnlock()
+		if breaker, ok := e.upstream.(*CircuitBreaker); ok {
+			health[i].Breaker = breaker.State().String()
+		}
+	}
+	return health
+}
@@ -0,0 +1,93 @@
+package dataCollection
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// newBrokenUpstream starts a local upstream that answers every request with an internal server error.
+func newBrokenUpstream() *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+}
+
+func TestFailoverUpstream_Post(t *testing.T) {
+	broken := newBrokenUpstream()
+	defer broken.Close()
+	working := newTestUpstream()
+	defer working.Close()
+
+	f := NewFailoverUpstream(broken.URL, working.URL)
+	lastBlock, err := NewClient(f).GetLastBlockNumber(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error \n%s", err.Error())
+	}
+	if lastBlock != 0x7fb25b {
+		t.Errorf("Expected: %d, got : %d", 0x7fb25b, lastBlock)
+	}
+	health := f.Health()
+	if health[0].Healthy || health[0].LastError != "status code 500" || !health[1].Healthy {
+		t.Errorf("Unexpected health %+v", health)
+	}
+
+	// with every endpoint out of rotation they are still tried
+	f = NewFailoverUpstream(broken.URL)
+	gotStatus, _, _, _ := f.Post(context.Background(), nil)
+	if gotStatus != http.StatusInternalServerError {
+		t.Errorf("Expected: %d, got : %d", http.StatusInternalServerError, gotStatus)
+	}
+
+	if _, _, _, err = NewFailoverUpstream().Post(context.Background(), nil); err == nil {
+		t.Error("expected error without upstreams")
+	}
+}
+
+func TestFailoverUpstream_slow(t *testing.T) {
+	slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(2 * time.Second):
+		}
+	}))
+	defer slow.Close()
+	working := newTestUpstream()
+	defer working.Close()
+
+	// the slow endpoint times out before the deadline of the request, leaving time to the next one
+	f := NewFailoverUpstream(slow.URL, working.URL)
+	ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+	defer cancel()
+	lastBlock, err := NewClient(f).GetLastBlockNumber(ctx)
+	if err != nil {
+		t.Fatalf("unexpected error \n%s", err.Error())
+	}
+	if lastBlock != 0x7fb25b {
+		t.Errorf("Expected: %d, got : %d", 0x7fb25b, lastBlock)
+	}
+	if health := f.Health(); health[0].Healthy || !health[1].Healthy {
+		t.Errorf("Unexpected health %+v", health)
+	}
+}
+
+func TestFailoverUpstream_HealthCheck(t *testing.T) {
+	working := newTestUpstream()
+	defer working.Close()
+
+	f := NewFailoverUpstream("http://127.0.0.1:0", working.URL)
+	f.endpoints[1].markUnhealthy(errTest)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	f.HealthCheck(ctx, time.Minute, time.Second)
+
+	health := f.Health()
+	if health[0].Healthy || health[0].LastError == "" {
+		t.Errorf("Expected unreachable upstream out of rotation, got %+v", health[0])
+	}
+	if !health[1].Healthy || health[1].LastBlock != 0x7fb25b || health[1].LastError != "" {
+		t.Errorf("Expected recovered upstream back in rotation, got %+v", health[1])
+	}
+}
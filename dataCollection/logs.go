@@ -0,0 +1,168 @@
+package dataCollection
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/LucaPaterlini/infura/config"
+	"strings"
+	"time"
+)
+
+// LogFilter selects the logs emitted in a range of blocks, optionally only by the given addresses
+// and with the given topics, each position of Topics lists the alternatives accepted, nil accepts any topic.
+type LogFilter struct {
+	FromBlock Uint64     `json:"fromBlock"`
+	ToBlock   Uint64     `json:"toBlock"`
+	Addresses []string   `json:"address,omitempty"`
+	Topics    [][]string `json:"topics,omitempty"`
+}
+
+// ChunkStore stores the results of the chunks of the range queries, the cache adapters satisfy it.
+type ChunkStore interface {
+	Get(key string) ([]byte, bool)
+	Set(key string, value []byte, expiration time.Time)
+	Release(key string)
+}
+
+// GetLogs using the third party api gets the logs selected by the filter with a single eth_getLogs,
+// when the third party refuses the range as too large or with too many results it is split in halves.
+// If the third party is not able to provide an answer before the context is done it returns timeout error.
+func (c *Client) GetLogs(ctx context.Context, filter LogFilter) ([]Log, error) {
+	logs := []Log{}
+	_, err := c.call(ctx, &logs, "eth_getLogs", filter)
+	if err != nil && filter.FromBlock < filter.ToBlock && tooManyLogs(err) {
+		middle := filter.FromBlock + (filter.ToBlock-filter.FromBlock)/2
+		first, second := filter, filter
+		first.ToBlock, second.FromBlock = middle, middle+1
+		var more []Log
+		if logs, err = c.GetLogs(ctx, first); err != nil {
+			return nil, err
+		}
+		if more, err = c.GetLogs(ctx, second); err != nil {
+			return nil, err
+		}
+		return append(logs, more...), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return logs, nil
+}
+
+// tooManyLogs reports if the upstream refused an eth_getLogs for its range or the size of its result.
+func tooManyLogs(err error) bool {
+	var rpcErr *RPCError
+	if !errors.As(err, &rpcErr) || !errors.Is(err, ErrInvalidParams) {
+		return false
+	}
+	message := strings.ToLower(rpcErr.Message)
+	return strings.Contains(message, "range") || strings.Contains(message, "results") || strings.Contains(message, "size")
+}
+
+// GetLogsRange gets the logs selected by the filter splitting its range in chunks of config.LogsChunkSize blocks,
+// aligned to multiples of the chunk size, fetched with up to config.LogsConcurrency requests in flight
+// and merged in order. The chunks ending at or before the confirmed block can not change anymore,
+// they are read from the store, when not nil, and saved into it so that they are never requested again,
+// unless the filter selects all the addresses and covers less than half of the chunk.
+func (c *Client) GetLogsRange(ctx context.Context, filter LogFilter, confirmed uint64, store ChunkStore) ([]Log, error) {
+	chunks := splitRange(uint64(filter.FromBlock), uint64(filter.ToBlock), config.LogsChunkSize)
+	results := make([][]Log, len(chunks))
+	err := forEach(ctx, len(chunks), config.LogsConcurrency, func(ctx context.Context, i int) error {
+		chunkFilter := filter
+		chunkFilter.FromBlock, chunkFilter.ToBlock = Uint64(chunks[i][0]), Uint64(chunks[i][1])
+		var logs []Log
+		var err error
+		if chunks[i][1] <= confirmed && store != nil && widenChunk(filter, chunks[i]) {
+			logs, err = c.getConfirmedLogs(ctx, chunkFilter, store)
+		} else {
+			// the chunks that can still change, or barely covered by the filter, are only requested for its blocks
+			chunkFilter.FromBlock, chunkFilter.ToBlock = maxUint64(filter.FromBlock, chunkFilter.FromBlock), minUint64(filter.ToBlock, chunkFilter.ToBlock)
+			logs, err = c.GetLogs(ctx, chunkFilter)
+		}
+		if err != nil {
+			return err
+		}
+		results[i] = trimLogs(logs, uint64(filter.FromBlock), uint64(filter.ToBlock))
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	logs := []Log{}
+	for _, result := range results {
+		logs = append(logs, result...)
+	}
+	return logs, nil
+}
+
+// widenChunk reports if the logs of the whole chunk are worth requesting and caching for the filter, as it selects
+// the logs of some addresses or covers at least half of the chunk. The chunks of the filters of all the addresses
+// barely covered could hold many more logs than the ones selected, that would be requested in many halves.
+func widenChunk(filter LogFilter, chunk [2]uint64) bool {
+	if len(filter.Addresses) > 0 {
+		return true
+	}
+	from, to := maxUint64(filter.FromBlock, Uint64(chunk[0])), minUint64(filter.ToBlock, Uint64(chunk[1]))
+	return 2*(uint64(to-from)+1) >= chunk[1]-chunk[0]+1
+}
+
+// getConfirmedLogs gets the logs of a confirmed chunk from the store, requesting and saving them when missing.
+func (c *Client) getConfirmedLogs(ctx context.Context, filter LogFilter, store ChunkStore) ([]Log, error) {
+	key, _ := json.Marshal(filter)
+	if value, ok := store.Get("logs:" + string(key)); ok {
+		var logs []Log
+		if err := json.Unmarshal(value, &logs); err == nil {
+			return logs, nil
+		}
+	}
+	logs, err := c.GetLogs(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	value, _ := json.Marshal(logs)
+	store.Set("logs:"+string(key), value, time.Now().Add(config.CacheImmutableTime))
+	return logs, nil
+}
+
+// splitRange splits the range of blocks from-to in chunks aligned to multiples of size,
+// the first and the last chunks extend beyond the range to their alignment.
+func splitRange(from, to, size uint64) (chunks [][2]uint64) {
+	for start := from - from%size; start <= to; start += size {
+		end := start + size - 1
+		if end < start {
+			// the last chunk of the uint64 range
+			end = ^uint64(0)
+		}
+		chunks = append(chunks, [2]uint64{start, end})
+		if end >= to {
+			break
+		}
+	}
+	return
+}
+
+// trimLogs returns the logs emitted in the blocks from-to.
+func trimLogs(logs []Log, from, to uint64) []Log {
+	trimmed := logs[:0:0]
+	for _, log := range logs {
+		if uint64(log.BlockNumber) >= from && uint64(log.BlockNumber) <= to {
+			trimmed = append(trimmed, log)
+		}
+	}
+	return trimmed
+}
+
+func minUint64(a, b Uint64) Uint64 {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func maxUint64(a, b Uint64) Uint64 {
+	if a > b {
+		return a
+	}
+	return b
+}
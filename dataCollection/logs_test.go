@@ -0,0 +1,183 @@
+package dataCollection
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/go-test/deep"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestSplitRange(t *testing.T) {
+	for _, tc := range []struct {
+		from, to, size uint64
+		expected       [][2]uint64
+	}{
+		{from: 0, to: 0, size: 10, expected: [][2]uint64{{0, 9}}},
+		{from: 5, to: 9, size: 10, expected: [][2]uint64{{0, 9}}},
+		{from: 5, to: 10, size: 10, expected: [][2]uint64{{0, 9}, {10, 19}}},
+		{from: 10, to: 35, size: 10, expected: [][2]uint64{{10, 19}, {20, 29}, {30, 39}}},
+		{from: ^uint64(0) - 1, to: ^uint64(0), size: 1 << 63, expected: [][2]uint64{{1 << 63, ^uint64(0)}}},
+	} {
+		if diff := deep.Equal(tc.expected, splitRange(tc.from, tc.to, tc.size)); diff != nil {
+			t.Errorf("splitRange(%d,%d,%d) %v", tc.from, tc.to, tc.size, diff)
+		}
+	}
+}
+
+// newLogsUpstream returns the endpoint that answers eth_getLogs with a log for every block of the range,
+// refusing the ranges longer than maxRange blocks as the providers do, it counts the requests received.
+func newLogsUpstream(maxRange uint64, calls *int64) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt64(calls, 1)
+		var req struct {
+			Params []LogFilter
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || len(req.Params) != 1 {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		filter := req.Params[0]
+		if uint64(filter.ToBlock-filter.FromBlock) >= maxRange {
+			_, _ = w.Write([]byte(`{"jsonrpc":"2.0","id":1,"error":{"code":-32005,"message":"query returned more than 10000 results"}}`))
+			return
+		}
+		address := "0xdac17f958d2ee523a2206206994597c13d831ec7"
+		if len(filter.Addresses) > 0 {
+			address = filter.Addresses[0]
+		}
+		logs := []Log{}
+		for block := filter.FromBlock; block <= filter.ToBlock; block++ {
+			logs = append(logs, Log{BlockNumber: block, Address: address, Topics: []string{}})
+		}
+		result, _ := json.Marshal(logs)
+		_, _ = fmt.Fprintf(w, `{"jsonrpc":"2.0","id":1,"result":%s}`, result)
+	}))
+}
+
+// blocksOf returns the block numbers of the logs.
+func blocksOf(logs []Log) (blocks []uint64) {
+	for _, log := range logs {
+		blocks = append(blocks, uint64(log.BlockNumber))
+	}
+	return
+}
+
+func rangeOf(from, to uint64) (blocks []uint64) {
+	for block := from; block <= to; block++ {
+		blocks = append(blocks, block)
+	}
+	return
+}
+
+func TestGetLogs_split(t *testing.T) {
+	var calls int64
+	ts := newLogsUpstream(10, &calls)
+	defer ts.Close()
+	filter := LogFilter{FromBlock: 100, ToBlock: 139, Addresses: []string{"0xdac17f958d2ee523a2206206994597c13d831ec7"}}
+	logs, err := NewClient(NewHTTPUpstream(ts.URL)).GetLogs(context.Background(), filter)
+	if err != nil {
+		t.Fatalf("unexpected error %s", err)
+	}
+	if diff := deep.Equal(rangeOf(100, 139), blocksOf(logs)); diff != nil {
+		t.Error(diff)
+	}
+	// 40 blocks are refused, then split in 2 refused halves of 20 and 4 accepted quarters of 10
+	if calls != 7 {
+		t.Errorf("Expected: 7 calls got : %d", calls)
+	}
+}
+
+// mapStore is the ChunkStore that keeps the chunks in a map.
+type mapStore struct {
+	mtx    sync.Mutex
+	chunks map[string][]byte
+}
+
+func (s *mapStore) Get(key string) ([]byte, bool) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	value, ok := s.chunks[key]
+	return value, ok
+}
+
+func (s *mapStore) Set(key string, value []byte, expiration time.Time) {
+	s.mtx.Lock()
+	s.chunks[key] = value
+	s.mtx.Unlock()
+}
+
+func (s *mapStore) Release(key string) {
+	s.mtx.Lock()
+	delete(s.chunks, key)
+	s.mtx.Unlock()
+}
+
+func TestGetLogsRange(t *testing.T) {
+	var calls int64
+	ts := newLogsUpstream(^uint64(0), &calls)
+	defer ts.Close()
+	client := NewClient(NewHTTPUpstream(ts.URL))
+	store := &mapStore{chunks: make(map[string][]byte)}
+	filter := LogFilter{FromBlock: 1990, ToBlock: 4010, Addresses: []string{"0xdac17f958d2ee523a2206206994597c13d831ec7"}}
+
+	// the chunks 0-1999 and 2000-3999 are confirmed, 4000-4010 is not
+	for i, expectedCalls := range []int64{3, 4} {
+		logs, err := client.GetLogsRange(context.Background(), filter, 4000, store)
+		if err != nil {
+			t.Fatalf("unexpected error %s", err)
+		}
+		if diff := deep.Equal(rangeOf(1990, 4010), blocksOf(logs)); diff != nil {
+			t.Error(diff)
+		}
+		if got := atomic.LoadInt64(&calls); got != expectedCalls {
+			t.Errorf("query %d Expected: %d calls got : %d", i, expectedCalls, got)
+		}
+	}
+	if len(store.chunks) != 2 {
+		t.Errorf("Expected: 2 confirmed chunks stored got : %d", len(store.chunks))
+	}
+
+	// a different query within the confirmed chunks does not reach the upstream
+	filter.FromBlock, filter.ToBlock = 2500, 2600
+	logs, err := client.GetLogsRange(context.Background(), filter, 4000, store)
+	if err != nil || len(logs) != 101 || atomic.LoadInt64(&calls) != 4 {
+		t.Errorf("Expected: 101 logs from the store got : %d %v after %d calls", len(logs), err, calls)
+	}
+}
+
+func TestGetLogsRange_allAddresses(t *testing.T) {
+	var calls int64
+	ts := newLogsUpstream(^uint64(0), &calls)
+	defer ts.Close()
+	client := NewClient(NewHTTPUpstream(ts.URL))
+	store := &mapStore{chunks: make(map[string][]byte)}
+
+	// the logs of all the addresses of a single confirmed block are requested only for the block
+	filter := LogFilter{FromBlock: 2500, ToBlock: 2500}
+	logs, err := client.GetLogsRange(context.Background(), filter, 4000, store)
+	if err != nil || len(logs) != 1 || atomic.LoadInt64(&calls) != 1 || len(store.chunks) != 0 {
+		t.Errorf("Expected: 1 log with 1 call and no chunk stored got : %d %v after %d calls with %d chunks", len(logs), err, calls, len(store.chunks))
+	}
+	// the ones covering most of a confirmed chunk are cached with the whole chunk
+	filter.FromBlock, filter.ToBlock = 2000, 3500
+	logs, err = client.GetLogsRange(context.Background(), filter, 4000, store)
+	if err != nil || len(logs) != 1501 || len(store.chunks) != 1 {
+		t.Errorf("Expected: 1501 logs and 1 chunk stored got : %d %v with %d chunks", len(logs), err, len(store.chunks))
+	}
+}
+
+func TestGetLogsRange_error(t *testing.T) {
+	ts := newStaticUpstream(http.StatusOK, `{"jsonrpc":"2.0","id":1,"error":{"code":-32602,"message":"invalid argument 0: hex string without 0x prefix"}}`)
+	defer ts.Close()
+	filter := LogFilter{FromBlock: 0, ToBlock: 10000}
+	if _, err := NewClient(NewHTTPUpstream(ts.URL)).GetLogsRange(context.Background(), filter, 0, nil); !errors.Is(err, ErrInvalidParams) {
+		t.Errorf("Expected: %v got : %v", ErrInvalidParams, err)
+	}
+}
@@ -0,0 +1,434 @@
+package dataCollection
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Uint64 is an unsigned integer encoded in JSON as a hex quantity, such as block numbers, gas and nonces.
+type Uint64 uint64
+
+// MarshalJSON encodes the value as a hex quantity.
+func (u Uint64) MarshalJSON() ([]byte, error) {
+	return []byte(`"0x` + strconv.FormatUint(uint64(u), 16) + `"`), nil
+}
+
+// UnmarshalJSON decodes a hex quantity.
+func (u *Uint64) UnmarshalJSON(data []byte) error {
+	s, err := unquoteQuantity(data)
+	if err != nil {
+		return err
+	}
+	value, err := strconv.ParseUint(s, 16, 64)
+	if err != nil {
+		return fmt.Errorf("invalid quantity %s: %s", data, err)
+	}
+	*u = Uint64(value)
+	return nil
+}
+
+// Quantity is an integer of arbitrary size encoded in JSON as a hex quantity, such as values in wei and difficulties.
+type Quantity big.Int
+
+// NewQuantity returns the Quantity of the given value.
+func NewQuantity(value *big.Int) *Quantity {
+	return (*Quantity)(new(big.Int).Set(value))
+}
+
+// Big returns the value of the quantity as big.Int.
+func (q *Quantity) Big() *big.Int {
+	return (*big.Int)(q)
+}
+
+// String returns the value of the quantity in base 10.
+func (q *Quantity) String() string {
+	return q.Big().String()
+}
+
+// MarshalJSON encodes the value as a hex quantity.
+func (q *Quantity) MarshalJSON() ([]byte, error) {
+	return []byte(`"0x` + q.Big().Text(16) + `"`), nil
+}
+
+// UnmarshalJSON decodes a hex quantity.
+func (q *Quantity) UnmarshalJSON(data []byte) error {
+	s, err := unquoteQuantity(data)
+	if err != nil {
+		return err
+	}
+	if _, ok := q.Big().SetString(s, 16); !ok {
+		return fmt.Errorf("invalid quantity %s", data)
+	}
+	return nil
+}
+
+// unquoteQuantity returns the hex digits of a quantity, validating its format.
+func unquoteQuantity(data []byte) (string, error) {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return "", fmt.Errorf("invalid quantity %s: %s", data, err)
+	}
+	if len(s) < 3 || s[:2] != "0x" {
+		return "", fmt.Errorf("invalid quantity %s", data)
+	}
+	return s[2:], nil
+}
+
+// Block is a block as returned by eth_getBlockByNumber and eth_getBlockByHash,
+// the fields are kept in the same order of the upstream response.
+// The fields not modeled, such as the ones of the L2 chains, are kept in Extra and encoded again.
+type Block struct {
+	BaseFeePerGas         *Quantity         `json:"baseFeePerGas,omitempty"`
+	BlobGasUsed           *Uint64           `json:"blobGasUsed,omitempty"`
+	Difficulty            *Quantity         `json:"difficulty"`
+	ExcessBlobGas         *Uint64           `json:"excessBlobGas,omitempty"`
+	ExtraData             string            `json:"extraData"`
+	GasLimit              Uint64            `json:"gasLimit"`
+	GasUsed               Uint64            `json:"gasUsed"`
+	Hash                  string            `json:"hash"`
+	LogsBloom             string            `json:"logsBloom"`
+	Miner                 string            `json:"miner"`
+	MixHash               string            `json:"mixHash"`
+	Nonce                 string            `json:"nonce"`
+	Number                Uint64            `json:"number"`
+	ParentBeaconBlockRoot string            `json:"parentBeaconBlockRoot,omitempty"`
+	ParentHash            string            `json:"parentHash"`
+	ReceiptsRoot          string            `json:"receiptsRoot"`
+	RequestsHash          string            `json:"requestsHash,omitempty"`
+	Sha3Uncles            string            `json:"sha3Uncles"`
+	Size                  Uint64            `json:"size"`
+	StateRoot             string            `json:"stateRoot"`
+	Timestamp             Uint64            `json:"timestamp"`
+	TotalDifficulty       *Quantity         `json:"totalDifficulty,omitempty"`
+	Transactions          BlockTransactions `json:"transactions"`
+	TransactionsRoot      string            `json:"transactionsRoot"`
+	Uncles                []string          `json:"uncles"`
+	Withdrawals           []Withdrawal      `json:"withdrawals,omitempty"`
+	WithdrawalsRoot       string            `json:"withdrawalsRoot,omitempty"`
+	// Extra contains the fields of the upstream response that are not modeled
+	Extra map[string]json.RawMessage `json:"-"`
+}
+
+// blockFields is the Block without its methods, to encode and decode its modeled fields.
+type blockFields Block
+
+// MarshalJSON encodes the block with its extra fields.
+func (b Block) MarshalJSON() ([]byte, error) {
+	return marshalWithExtra(blockFields(b), b.Extra)
+}
+
+// UnmarshalJSON decodes the block keeping the fields not modeled in Extra.
+func (b *Block) UnmarshalJSON(data []byte) (err error) {
+	b.Extra, err = unmarshalWithExtra(data, (*blockFields)(b))
+	return err
+}
+
+// WithHashes returns the block listing only the hashes of its transactions, as requested without the full objects.
+func (b *Block) WithHashes() *Block {
+	light := *b
+	if b.Transactions.Full != nil {
+		light.Transactions = BlockTransactions{Hashes: make([]string, len(b.Transactions.Full))}
+		for i, tx := range b.Transactions.Full {
+			light.Transactions.Hashes[i] = tx.Hash
+		}
+	}
+	return &light
+}
+
+// Header is a block without the list of its transactions.
+type Header struct {
+	*Block
+}
+
+// MarshalJSON encodes the block without the list of its transactions.
+func (h Header) MarshalJSON() ([]byte, error) {
+	data, err := json.Marshal(h.Block)
+	if err != nil {
+		return nil, err
+	}
+	var fields map[string]json.RawMessage
+	if err = json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+	delete(fields, "transactions")
+	return json.Marshal(fields)
+}
+
+// Header returns the header of the block.
+func (b *Block) Header() *Header {
+	return &Header{Block: b}
+}
+
+// BlockTransactions contains the transactions of a block, as hashes or as full objects depending on the request.
+type BlockTransactions struct {
+	Hashes []string
+	Full   []Transaction
+}
+
+// MarshalJSON encodes the full transactions when available, the hashes otherwise.
+func (t BlockTransactions) MarshalJSON() ([]byte, error) {
+	if t.Full != nil {
+		return json.Marshal(t.Full)
+	}
+	if t.Hashes == nil {
+		return []byte("[]"), nil
+	}
+	return json.Marshal(t.Hashes)
+}
+
+// UnmarshalJSON decodes either a list of hashes or a list of full transactions.
+func (t *BlockTransactions) UnmarshalJSON(data []byte) error {
+	var items []json.RawMessage
+	if err := json.Unmarshal(data, &items); err != nil {
+		return err
+	}
+	if len(items) == 0 {
+		*t = BlockTransactions{Hashes: []string{}}
+		return nil
+	}
+	if bytes.HasPrefix(bytes.TrimSpace(items[0]), []byte(`"`)) {
+		t.Full = nil
+		return json.Unmarshal(data, &t.Hashes)
+	}
+	t.Hashes = nil
+	return json.Unmarshal(data, &t.Full)
+}
+
+// Transaction is a transaction as returned by eth_getTransactionByHash and eth_getTransactionByBlockNumberAndIndex,
+// the fields not modeled are kept in Extra and encoded again.
+type Transaction struct {
+	// AccessList is a pointer so that the empty list of the typed transactions is encoded again
+	AccessList           *[]AccessTuple  `json:"accessList,omitempty"`
+	AuthorizationList    []Authorization `json:"authorizationList,omitempty"`
+	BlobVersionedHashes  []string        `json:"blobVersionedHashes,omitempty"`
+	BlockHash            *string         `json:"blockHash"`
+	BlockNumber          *Uint64         `json:"blockNumber"`
+	ChainID              *Quantity       `json:"chainId,omitempty"`
+	From                 string          `json:"from"`
+	Gas                  Uint64          `json:"gas"`
+	GasPrice             *Quantity       `json:"gasPrice,omitempty"`
+	Hash                 string          `json:"hash"`
+	Input                string          `json:"input"`
+	MaxFeePerBlobGas     *Quantity       `json:"maxFeePerBlobGas,omitempty"`
+	MaxFeePerGas         *Quantity       `json:"maxFeePerGas,omitempty"`
+	MaxPriorityFeePerGas *Quantity       `json:"maxPriorityFeePerGas,omitempty"`
+	Nonce                Uint64          `json:"nonce"`
+	R                    *Quantity       `json:"r"`
+	S                    *Quantity       `json:"s"`
+	To                   *string         `json:"to"`
+	TransactionIndex     *Uint64         `json:"transactionIndex"`
+	Type                 *Uint64         `json:"type,omitempty"`
+	V                    *Quantity       `json:"v"`
+	Value                *Quantity       `json:"value"`
+	YParity              *Uint64         `json:"yParity,omitempty"`
+	// Extra contains the fields of the upstream response that are not modeled
+	Extra map[string]json.RawMessage `json:"-"`
+}
+
+// transactionFields is the Transaction without its methods, to encode and decode its modeled fields.
+type transactionFields Transaction
+
+// MarshalJSON encodes the transaction with its extra fields.
+func (t Transaction) MarshalJSON() ([]byte, error) {
+	return marshalWithExtra(transactionFields(t), t.Extra)
+}
+
+// UnmarshalJSON decodes the transaction keeping the fields not modeled in Extra.
+func (t *Transaction) UnmarshalJSON(data []byte) (err error) {
+	t.Extra, err = unmarshalWithExtra(data, (*transactionFields)(t))
+	return err
+}
+
+// Authorization is an entry of the authorization list of an EIP-7702 transaction.
+type Authorization struct {
+	Address string    `json:"address"`
+	ChainID *Quantity `json:"chainId"`
+	Nonce   Uint64    `json:"nonce"`
+	R       *Quantity `json:"r"`
+	S       *Quantity `json:"s"`
+	YParity Uint64    `json:"yParity"`
+}
+
+// AccessTuple is an entry of the access list of a transaction.
+type AccessTuple struct {
+	Address     string   `json:"address"`
+	StorageKeys []string `json:"storageKeys"`
+}
+
+// Withdrawal is a validator withdrawal included in a block.
+type Withdrawal struct {
+	Address        string `json:"address"`
+	Amount         Uint64 `json:"amount"`
+	Index          Uint64 `json:"index"`
+	ValidatorIndex Uint64 `json:"validatorIndex"`
+}
+
+// Receipt is the receipt of a transaction as returned by eth_getTransactionReceipt,
+// the fields not modeled, such as the L1 fees of the L2 chains, are kept in Extra and encoded again.
+type Receipt struct {
+	BlobGasPrice      *Quantity `json:"blobGasPrice,omitempty"`
+	BlobGasUsed       *Uint64   `json:"blobGasUsed,omitempty"`
+	BlockHash         string    `json:"blockHash"`
+	BlockNumber       Uint64    `json:"blockNumber"`
+	ContractAddress   *string   `json:"contractAddress"`
+	CumulativeGasUsed Uint64    `json:"cumulativeGasUsed"`
+	EffectiveGasPrice *Quantity `json:"effectiveGasPrice,omitempty"`
+	From              string    `json:"from"`
+	GasUsed           Uint64    `json:"gasUsed"`
+	Logs              []Log     `json:"logs"`
+	LogsBloom         string    `json:"logsBloom"`
+	Root              string    `json:"root,omitempty"`
+	Status            *Uint64   `json:"status,omitempty"`
+	To                *string   `json:"to"`
+	TransactionHash   string    `json:"transactionHash"`
+	TransactionIndex  Uint64    `json:"transactionIndex"`
+	Type              *Uint64   `json:"type,omitempty"`
+	// Extra contains the fields of the upstream response that are not modeled
+	Extra map[string]json.RawMessage `json:"-"`
+}
+
+// receiptFields is the Receipt without its methods, to encode and decode its modeled fields.
+type receiptFields Receipt
+
+// MarshalJSON encodes the receipt with its extra fields.
+func (r Receipt) MarshalJSON() ([]byte, error) {
+	return marshalWithExtra(receiptFields(r), r.Extra)
+}
+
+// UnmarshalJSON decodes the receipt keeping the fields not modeled in Extra.
+func (r *Receipt) UnmarshalJSON(data []byte) (err error) {
+	r.Extra, err = unmarshalWithExtra(data, (*receiptFields)(r))
+	return err
+}
+
+// modeledFields caches the JSON names of the fields of each model.
+var modeledFields sync.Map
+
+// unmarshalWithExtra decodes data into v, a pointer to a model without its methods,
+// and returns the fields of data that the model does not have, nil when there are none.
+func unmarshalWithExtra(data []byte, v interface{}) (map[string]json.RawMessage, error) {
+	if err := json.Unmarshal(data, v); err != nil {
+		return nil, err
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+	for name := range fieldNames(reflect.TypeOf(v).Elem()) {
+		delete(fields, name)
+	}
+	if len(fields) == 0 {
+		return nil, nil
+	}
+	return fields, nil
+}
+
+// marshalWithExtra encodes v, a model without its methods, with the extra fields it does not have,
+// all of them in alphabetical order as the modeled ones.
+func marshalWithExtra(v interface{}, extra map[string]json.RawMessage) ([]byte, error) {
+	data, err := json.Marshal(v)
+	if err != nil || len(extra) == 0 {
+		return data, err
+	}
+	var fields map[string]json.RawMessage
+	if err = json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+	for name, value := range extra {
+		if _, ok := fields[name]; !ok {
+			fields[name] = value
+		}
+	}
+	return json.Marshal(fields)
+}
+
+// fieldNames returns the JSON names of the fields of the struct type t.
+func fieldNames(t reflect.Type) map[string]bool {
+	if names, ok := modeledFields.Load(t); ok {
+		return names.(map[string]bool)
+	}
+	names := make(map[string]bool, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		name := strings.Split(t.Field(i).Tag.Get("json"), ",")[0]
+		if name == "" {
+			name = t.Field(i).Name
+		}
+		names[name] = true
+	}
+	modeledFields.Store(t, names)
+	return names
+}
+
+// Log is an event emitted by a transaction.
+type Log struct {
+	Address          string   `json:"address"`
+	BlockHash        string   `json:"blockHash"`
+	BlockNumber      Uint64   `json:"blockNumber"`
+	Data             string   `json:"data"`
+	LogIndex         Uint64   `json:"logIndex"`
+	Removed          bool     `json:"removed"`
+	Topics           []string `json:"topics"`
+	TransactionHash  string   `json:"transactionHash"`
+	TransactionIndex Uint64   `json:"transactionIndex"`
+}
+
+// Response is the envelope of a JSON-RPC response.
+type Response struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  json.RawMessage `json:"result,omitempty"`
+	Error   *RPCError       `json:"error,omitempty"`
+}
+
+// Decode unmarshals the result of the JSON-RPC response body into v,
+// it reports false without touching v when the result is null,
+// and returns the RPCError when the response contains an error object.
+func Decode(body []byte, v interface{}) (found bool, err error) {
+	var resp Response
+	if err = json.Unmarshal(body, &resp); err != nil {
+		return false, fmt.Errorf("invalid JSON-RPC response: %s", err)
+	}
+	if resp.Error != nil {
+		return false, resp.Error
+	}
+	if len(resp.Result) == 0 || bytes.Equal(resp.Result, []byte("null")) {
+		return false, nil
+	}
+	if err = json.Unmarshal(resp.Result, v); err != nil {
+		return false, fmt.Errorf("invalid JSON-RPC result: %s", err)
+	}
+	return true, nil
+}
+
+// DecodeBlock decodes the block contained in the JSON-RPC response body, nil if the block does not exist.
+func DecodeBlock(body []byte) (*Block, error) {
+	block := new(Block)
+	if found, err := Decode(body, block); !found {
+		return nil, err
+	}
+	return block, nil
+}
+
+// DecodeTransaction decodes the transaction contained in the JSON-RPC response body, nil if the transaction does not exist.
+func DecodeTransaction(body []byte) (*Transaction, error) {
+	tx := new(Transaction)
+	if found, err := Decode(body, tx); !found {
+		return nil, err
+	}
+	return tx, nil
+}
+
+// DecodeReceipt decodes the receipt contained in the JSON-RPC response body, nil if the receipt does not exist.
+func DecodeReceipt(body []byte) (*Receipt, error) {
+	receipt := new(Receipt)
+	if found, err := Decode(body, receipt); !found {
+		return nil, err
+	}
+	return receipt, nil
+}
@@ -0,0 +1,197 @@
+package dataCollection
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/go-test/deep"
+	"math/big"
+	"testing"
+)
+
+func TestQuantity(t *testing.T) {
+	for _, tc := range []struct {
+		input         string
+		expected      string
+		expectedError bool
+	}{
+		{input: `"0x0"`, expected: "0"},
+		{input: `"0x872add2075a0f"`, expected: "2377890692291087"},
+		{input: `"0x26faabe35e84680a6d9"`, expected: "11504624888424804951769"},
+		{input: `"0x"`, expectedError: true},
+		{input: `"12"`, expectedError: true},
+		{input: `"0xzz"`, expectedError: true},
+		{input: `12`, expectedError: true},
+	} {
+		q := new(Quantity)
+		err := json.Unmarshal([]byte(tc.input), q)
+		switch {
+		case tc.expectedError && err == nil:
+			t.Errorf("%s expected error", tc.input)
+		case !tc.expectedError && err != nil:
+			t.Errorf("%s unexpected error %s", tc.input, err)
+		case !tc.expectedError:
+			if q.String() != tc.expected {
+				t.Errorf("Expected: %s, got : %s", tc.expected, q.String())
+			}
+			if got, _ := json.Marshal(q); string(got) != tc.input {
+				t.Errorf("Expected: %s, got : %s", tc.input, got)
+			}
+		}
+	}
+
+	if got, _ := json.Marshal(NewQuantity(big.NewInt(255))); string(got) != `"0xff"` {
+		t.Errorf("Expected: %s, got : %s", `"0xff"`, got)
+	}
+}
+
+func TestUint64(t *testing.T) {
+	var u Uint64
+	if err := json.Unmarshal([]byte(`"0x7fb021"`), &u); err != nil || u != 8368161 {
+		t.Errorf("Expected: %d, got : %d %v", 8368161, u, err)
+	}
+	if got, _ := json.Marshal(u); string(got) != `"0x7fb021"` {
+		t.Errorf("Expected: %s, got : %s", `"0x7fb021"`, got)
+	}
+	for _, input := range []string{`"0x10000000000000000"`, `"0x"`, `null`} {
+		if err := json.Unmarshal([]byte(input), &u); err == nil {
+			t.Errorf("%s expected error", input)
+		}
+	}
+}
+
+func TestBlockTransactions(t *testing.T) {
+	for _, input := range []string{
+		`[]`,
+		`["0x7adfcf6e2947590cb88763af73c923f1d1832c07830a8891199832e506103430"]`,
+		`[` + resultOf(recordedTransaction8368161) + `]`,
+	} {
+		var txs BlockTransactions
+		if err := json.Unmarshal([]byte(input), &txs); err != nil {
+			t.Errorf("%s unexpected error %s", input, err)
+			continue
+		}
+		if got, _ := json.Marshal(txs); string(got) != input {
+			t.Errorf("Expected: %s\nGot     : %s", input, got)
+		}
+	}
+	if got, _ := json.Marshal(BlockTransactions{}); string(got) != `[]` {
+		t.Errorf("Expected: [], got : %s", got)
+	}
+	if err := json.Unmarshal([]byte(`{}`), &BlockTransactions{}); err == nil {
+		t.Error("expected error")
+	}
+}
+
+func TestBlock_WithHashes(t *testing.T) {
+	var txs BlockTransactions
+	if err := json.Unmarshal([]byte(`[`+resultOf(recordedTransaction8368161)+`]`), &txs); err != nil {
+		t.Fatal(err)
+	}
+	block := &Block{Number: 8368161, Transactions: txs}
+	light := block.WithHashes()
+	expected := []string{"0x7adfcf6e2947590cb88763af73c923f1d1832c07830a8891199832e506103430"}
+	if diff := deep.Equal(expected, light.Transactions.Hashes); diff != nil || light.Transactions.Full != nil {
+		t.Errorf("%v full %d", diff, len(light.Transactions.Full))
+	}
+	if len(block.Transactions.Full) != 1 {
+		t.Error("the original block has been modified")
+	}
+}
+
+func TestBlock_Header(t *testing.T) {
+	block := &Block{Number: 12, Transactions: BlockTransactions{Hashes: []string{"0x01"}}}
+	got, _ := json.Marshal(block.Header())
+	if bytes.Contains(got, []byte(`"transactions"`)) || !bytes.Contains(got, []byte(`"number":"0xc","parentHash"`)) {
+		t.Errorf("unexpected header %s", got)
+	}
+}
+
+func TestDecodeBlock(t *testing.T) {
+	// post shanghai block with withdrawals and base fee
+	const result = `{"baseFeePerGas":"0x5e6e9bd9f","difficulty":"0x0","extraData":"0x","gasLimit":"0x1c9c380","gasUsed":"0x5208","hash":"0xa4b5","logsBloom":"0x00","miner":"0x95222290dd7278aa3ddd389cc1e1d165cc4bafe5","mixHash":"0x01","nonce":"0x0000000000000000","number":"0x1096a40","parentHash":"0xa4b4","receiptsRoot":"0x02","sha3Uncles":"0x03","size":"0x2a1","stateRoot":"0x04","timestamp":"0x6436c7f7","totalDifficulty":"0xc70d815d562d3cfa955","transactions":[],"transactionsRoot":"0x05","uncles":[],"withdrawals":[{"address":"0x8306300ffd616049fd7e4b0354a64da835c1a81c","amount":"0xcc0e8","index":"0x0","validatorIndex":"0x4d0c2"}],"withdrawalsRoot":"0x06"}`
+	block, err := DecodeBlock([]byte(`{"jsonrpc":"2.0","id":1,"result":` + result + `}`))
+	if err != nil {
+		t.Fatalf("unexpected error %s", err)
+	}
+	if block.Number != 0x1096a40 || block.Withdrawals[0].ValidatorIndex != 0x4d0c2 || block.BaseFeePerGas.String() != "25348914591" {
+		t.Errorf("Unexpected block %+v", block)
+	}
+	if got, _ := json.Marshal(block); string(got) != result {
+		t.Errorf("Expected: %s\nGot     : %s", result, got)
+	}
+
+	if block, err = DecodeBlock([]byte(`{"jsonrpc":"2.0","id":1,"result":null}`)); block != nil || err != nil {
+		t.Errorf("Expected nil block and error, got %v %v", block, err)
+	}
+	for _, body := range []string{`not json`, `{"jsonrpc":"2.0","id":1,"result":{"number":12}}`} {
+		if _, err = DecodeBlock([]byte(body)); err == nil {
+			t.Errorf("%s expected error", body)
+		}
+	}
+}
+
+func TestDecodeTransaction(t *testing.T) {
+	tx, err := DecodeTransaction([]byte(recordedTransaction8368161))
+	if err != nil {
+		t.Fatalf("unexpected error %s", err)
+	}
+	if *tx.BlockNumber != 8368161 || tx.Value.String() != "1630300000000000000" || *tx.To != "0x3b4c009fe957d58626efb439b463fccbe7538ab7" {
+		t.Errorf("Unexpected transaction %+v", tx)
+	}
+	if tx, err = DecodeTransaction([]byte(`{"jsonrpc":"2.0","id":1,"result":null}`)); tx != nil || err != nil {
+		t.Errorf("Expected nil transaction and error, got %v %v", tx, err)
+	}
+}
+
+func TestDecodeTransaction_extra(t *testing.T) {
+	// blob transaction with the authorization list of EIP-7702 and the field of a L2 chain not modeled
+	const result = `{"accessList":[],"authorizationList":[{"address":"0x63c0c19a282a1b52b07dd5a65b58948a07dae32b","chainId":"0x1","nonce":"0x2","r":"0x1","s":"0x2","yParity":"0x1"}],"blobVersionedHashes":["0x01a915e4d060149eb4365960e6a7a45f334393093061116b197e3240065ff2d8"],"blockHash":"0x01","blockNumber":"0x1","chainId":"0x1","from":"0x5e032243d507c743b061ef021e2ec7fcc6d3ab89","gas":"0x5208","hash":"0x02","input":"0x","l1BlockNumber":"0x12d6b0e","maxFeePerBlobGas":"0x3b9aca00","maxFeePerGas":"0x2","maxPriorityFeePerGas":"0x1","nonce":"0x0","r":"0x1","s":"0x2","to":"0x3b4c009fe957d58626efb439b463fccbe7538ab7","transactionIndex":"0x0","type":"0x3","v":"0x1","value":"0x0","yParity":"0x1"}`
+	tx, err := DecodeTransaction([]byte(`{"jsonrpc":"2.0","id":1,"result":` + result + `}`))
+	if err != nil {
+		t.Fatalf("unexpected error %s", err)
+	}
+	if len(tx.BlobVersionedHashes) != 1 || tx.MaxFeePerBlobGas.String() != "1000000000" || tx.AuthorizationList[0].Nonce != 2 {
+		t.Errorf("Unexpected transaction %+v", tx)
+	}
+	if diff := deep.Equal(map[string]json.RawMessage{"l1BlockNumber": json.RawMessage(`"0x12d6b0e"`)}, tx.Extra); diff != nil {
+		t.Error(diff)
+	}
+	if got, _ := json.Marshal(tx); string(got) != result {
+		t.Errorf("Expected: %s\nGot     : %s", result, got)
+	}
+}
+
+func TestDecodeBlock_extra(t *testing.T) {
+	// block with the requests hash of Prague and the field of a L2 chain not modeled
+	const result = `{"difficulty":"0x0","extraData":"0x","gasLimit":"0x1c9c380","gasUsed":"0x0","hash":"0xa4b5","l1BlockNumber":"0x12d6b0e","logsBloom":"0x00","miner":"0x95222290dd7278aa3ddd389cc1e1d165cc4bafe5","mixHash":"0x01","nonce":"0x0000000000000000","number":"0xc","parentHash":"0xa4b4","receiptsRoot":"0x02","requestsHash":"0xe3b0","sha3Uncles":"0x03","size":"0x2a1","stateRoot":"0x04","timestamp":"0x6436c7f7","transactions":[],"transactionsRoot":"0x05","uncles":[]}`
+	block, err := DecodeBlock([]byte(`{"jsonrpc":"2.0","id":1,"result":` + result + `}`))
+	if err != nil {
+		t.Fatalf("unexpected error %s", err)
+	}
+	if block.RequestsHash != "0xe3b0" || len(block.Extra) != 1 {
+		t.Errorf("Unexpected block %+v", block)
+	}
+	if got, _ := json.Marshal(block); string(got) != result {
+		t.Errorf("Expected: %s\nGot     : %s", result, got)
+	}
+	if got, _ := json.Marshal(block.Header()); bytes.Contains(got, []byte(`"transactions"`)) || !bytes.Contains(got, []byte(`"l1BlockNumber":"0x12d6b0e"`)) {
+		t.Errorf("unexpected header %s", got)
+	}
+}
+
+func TestDecodeReceipt(t *testing.T) {
+	const result = `{"blockHash":"0x4f56d43f13bee11e6ca9739d326e3935428bf1ceaf5b78c211f38709b561e269","blockNumber":"0x7fb021","contractAddress":null,"cumulativeGasUsed":"0x5208","effectiveGasPrice":"0xd09dc3000","from":"0x5e032243d507c743b061ef021e2ec7fcc6d3ab89","gasUsed":"0x5208","logs":[{"address":"0xdac17f958d2ee523a2206206994597c13d831ec7","blockHash":"0x4f56d43f13bee11e6ca9739d326e3935428bf1ceaf5b78c211f38709b561e269","blockNumber":"0x7fb021","data":"0x00","logIndex":"0x0","removed":false,"topics":["0xddf252ad1be2c89b69c2b068fc378daa952ba7f163c4a11628f55a4df523b3ef"],"transactionHash":"0x7adfcf6e2947590cb88763af73c923f1d1832c07830a8891199832e506103430","transactionIndex":"0x0"}],"logsBloom":"0x00","status":"0x1","to":"0x3b4c009fe957d58626efb439b463fccbe7538ab7","transactionHash":"0x7adfcf6e2947590cb88763af73c923f1d1832c07830a8891199832e506103430","transactionIndex":"0x0","type":"0x0"}`
+	receipt, err := DecodeReceipt([]byte(`{"jsonrpc":"2.0","id":1,"result":` + result + `}`))
+	if err != nil {
+		t.Fatalf("unexpected error %s", err)
+	}
+	if *receipt.Status != 1 || receipt.GasUsed != 21000 || receipt.ContractAddress != nil || len(receipt.Logs) != 1 {
+		t.Errorf("Unexpected receipt %+v", receipt)
+	}
+	if got, _ := json.Marshal(receipt); string(got) != result {
+		t.Errorf("Expected: %s\nGot     : %s", result, got)
+	}
+	if receipt, err = DecodeReceipt([]byte(`{"jsonrpc":"2.0","id":1,"result":null}`)); receipt != nil || err != nil {
+		t.Errorf("Expected nil receipt and error, got %v %v", receipt, err)
+	}
+}
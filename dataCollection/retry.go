@@ -0,0 +1,85 @@
+package dataCollection
+
+import (
+	"errors"
+	"github.com/LucaPaterlini/infura/config"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// RetryPolicy describes how the Client retries the requests failed for a transient reason,
+// the retries never exceed the timeout of the original request.
+type RetryPolicy struct {
+	// MaxAttempts is the maximum number of times a request is sent, 1 disables the retries.
+	MaxAttempts int
+	// BaseBackoff is the wait before the first retry, doubled at every following one.
+	BaseBackoff time.Duration
+	// MaxBackoff caps the wait between two attempts.
+	MaxBackoff time.Duration
+	// Jitter is the fraction, between 0 and 1, of each wait that is randomized to spread the retries.
+	Jitter float64
+	// RetryableErrors are the kinds of errors worth a retry.
+	RetryableErrors []error
+	// RetryableStatusCodes are the http statuses of the upstream worth a retry.
+	RetryableStatusCodes []int
+}
+
+// DefaultRetryPolicy retries timeouts, rate limits and upstream failures with the backoff set in the config.
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts:          config.RetryMaxAttempts,
+	BaseBackoff:          config.RetryBaseBackoff,
+	MaxBackoff:           config.RetryMaxBackoff,
+	Jitter:               0.2,
+	RetryableErrors:      []error{ErrTimeout, ErrRateLimited, ErrUpstreamInternal},
+	RetryableStatusCodes: []int{http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout},
+}
+
+// retryable reports if a request failed with the given status and error should be sent again.
+func (p RetryPolicy) retryable(statusCode int, err error) bool {
+	if err == nil {
+		return false
+	}
+	for _, code := range p.RetryableStatusCodes {
+		if statusCode == code {
+			return true
+		}
+	}
+	for _, kind := range p.RetryableErrors {
+		if errors.Is(err, kind) {
+			return true
+		}
+	}
+	return false
+}
+
+// backoff returns the wait before the next attempt, the Retry-After header of the upstream takes precedence.
+func (p RetryPolicy) backoff(attempt int, header map[string][]string) time.Duration {
+	if wait, ok := retryAfter(header, time.Now()); ok {
+		return wait
+	}
+	wait := p.BaseBackoff << uint(attempt-1)
+	if wait > p.MaxBackoff || wait <= 0 {
+		wait = p.MaxBackoff
+	}
+	return wait - time.Duration(rand.Float64()*p.Jitter*float64(wait))
+}
+
+// retryAfter parses the Retry-After header, expressed either in seconds or as an http date.
+func retryAfter(header map[string][]string, now time.Time) (time.Duration, bool) {
+	value := http.Header(header).Get("Retry-After")
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		if wait := date.Sub(now); wait > 0 {
+			return wait, true
+		}
+		return 0, true
+	}
+	return 0, false
+}
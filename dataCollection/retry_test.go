@@ -0,0 +1,107 @@
+package dataCollection
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// newFlakyUpstream starts a local upstream that answers with the given status and header
+// the first failures requests, and replays upstreamReplies afterwards.
+func newFlakyUpstream(failures int32, statusCode int, header http.Header) (*httptest.Server, *int32) {
+	var attempts int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&attempts, 1) <= failures {
+			for k, v := range header {
+				w.Header()[k] = v
+			}
+			w.WriteHeader(statusCode)
+			return
+		}
+		replayUpstreamReplies(w, r)
+	}))
+	return ts, &attempts
+}
+
+func TestClient_retry(t *testing.T) {
+	policy := RetryPolicy{
+		MaxAttempts:          3,
+		BaseBackoff:          time.Millisecond,
+		MaxBackoff:           5 * time.Millisecond,
+		Jitter:               0.5,
+		RetryableErrors:      []error{ErrTimeout},
+		RetryableStatusCodes: []int{http.StatusServiceUnavailable, http.StatusTooManyRequests},
+	}
+	for _, tc := range []struct {
+		failures         int32
+		statusCode       int
+		header           http.Header
+		requestTimeout   time.Duration
+		expectedError    error
+		expectedAttempts int32
+		description      string
+	}{
+		{2, http.StatusServiceUnavailable, nil, time.Second, nil, 3, "recovering upstream"},
+		{3, http.StatusServiceUnavailable, nil, time.Second, ErrUpstreamInternal, 3, "attempts exhausted"},
+		{1, http.StatusInternalServerError, nil, time.Second, ErrUpstreamInternal, 1, "not retryable status"},
+		{1, http.StatusTooManyRequests, http.Header{"Retry-After": {"0"}}, time.Second, nil, 2, "retry after honoured"},
+		{1, http.StatusTooManyRequests, http.Header{"Retry-After": {"10"}}, time.Second, ErrRateLimited, 1, "retry after beyond the deadline"},
+	} {
+		ts, attempts := newFlakyUpstream(tc.failures, tc.statusCode, tc.header)
+		client := NewClient(NewHTTPUpstream(ts.URL))
+		client.Retry = policy
+		ctx, cancel := context.WithTimeout(context.Background(), tc.requestTimeout)
+		_, err := client.GetLastBlockNumber(ctx)
+		cancel()
+		ts.Close()
+
+		switch {
+		case tc.expectedError == nil && err != nil:
+			t.Errorf("Test:%s, unexpected error %s", tc.description, err)
+		case tc.expectedError != nil && !errors.Is(err, tc.expectedError):
+			t.Errorf("Test:%s, Expected: %v, got : %v", tc.description, tc.expectedError, err)
+		}
+		if *attempts != tc.expectedAttempts {
+			t.Errorf("Test:%s, Expected attempts: %d, got : %d", tc.description, tc.expectedAttempts, *attempts)
+		}
+	}
+}
+
+func TestRetryPolicy_backoff(t *testing.T) {
+	policy := RetryPolicy{BaseBackoff: 100 * time.Millisecond, MaxBackoff: 300 * time.Millisecond}
+	for attempt, expected := range map[int]time.Duration{1: 100 * time.Millisecond, 2: 200 * time.Millisecond, 3: 300 * time.Millisecond, 70: 300 * time.Millisecond} {
+		if got := policy.backoff(attempt, nil); got != expected {
+			t.Errorf("attempt %d Expected: %s, got : %s", attempt, expected, got)
+		}
+	}
+	policy.Jitter = 1
+	for i := 0; i < 100; i++ {
+		if got := policy.backoff(2, nil); got <= 0 || got > 200*time.Millisecond {
+			t.Errorf("Expected backoff within (0, 200ms], got : %s", got)
+		}
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	now := time.Date(2019, 8, 17, 12, 0, 0, 0, time.UTC)
+	for _, tc := range []struct {
+		value         string
+		expected      time.Duration
+		expectedFound bool
+	}{
+		{"", 0, false},
+		{"3", 3 * time.Second, true},
+		{"Sat, 17 Aug 2019 12:00:05 GMT", 5 * time.Second, true},
+		{"Sat, 17 Aug 2019 11:00:00 GMT", 0, true},
+		{"soon", 0, false},
+	} {
+		got, gotFound := retryAfter(map[string][]string{"Retry-After": {tc.value}}, now)
+		if got != tc.expected || gotFound != tc.expectedFound {
+			t.Errorf("%q Expected: %s %v, got : %s %v", tc.value, tc.expected, tc.expectedFound, got, gotFound)
+		}
+	}
+}
@@ -0,0 +1,87 @@
+package dataCollection
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/LucaPaterlini/infura/config"
+	"github.com/gorilla/websocket"
+	"time"
+)
+
+// notification is the envelope of the messages pushed by the upstream for a subscription.
+type notification struct {
+	Method string `json:"method"`
+	Params struct {
+		Subscription string          `json:"subscription"`
+		Result       json.RawMessage `json:"result"`
+	} `json:"params"`
+}
+
+// SubscribeNewHeads subscribes to the newHeads of the JSON-RPC WebSocket endpoint at url and calls onHead
+// with the number of every new block as soon as it is announced.
+// It blocks until the context is done or the socket drops, no head for config.HeadStaleTime counts as dropped,
+// and always returns the reason it stopped.
+func SubscribeNewHeads(ctx context.Context, url string, onHead func(blockNumber uint64)) error {
+	conn, _, err := websocket.DefaultDialer.DialContext(ctx, url, nil)
+	if err != nil {
+		return transportError(err)
+	}
+	defer conn.Close()
+	// unblock the reads as soon as the context is done
+	stop := make(chan struct{})
+	defer close(stop)
+	go func() {
+		select {
+		case <-ctx.Done():
+			_ = conn.Close()
+		case <-stop:
+		}
+	}()
+
+	err = conn.WriteJSON(request{JSONRPC: "2.0", Method: "eth_subscribe", Params: []interface{}{"newHeads"}, ID: 1})
+	if err != nil {
+		return contextError(ctx, err)
+	}
+	_ = conn.SetReadDeadline(time.Now().Add(config.HeadStaleTime))
+	_, body, err := conn.ReadMessage()
+	if err != nil {
+		return contextError(ctx, err)
+	}
+	var subscription string
+	if found, err := Decode(body, &subscription); err != nil {
+		return err
+	} else if !found {
+		return fmt.Errorf("eth_subscribe returned no subscription")
+	}
+
+	for {
+		_ = conn.SetReadDeadline(time.Now().Add(config.HeadStaleTime))
+		_, body, err := conn.ReadMessage()
+		if err != nil {
+			return contextError(ctx, err)
+		}
+		var msg notification
+		if err := json.Unmarshal(body, &msg); err != nil {
+			return err
+		}
+		if msg.Method != "eth_subscription" || msg.Params.Subscription != subscription {
+			continue
+		}
+		var head struct {
+			Number Uint64 `json:"number"`
+		}
+		if err := json.Unmarshal(msg.Params.Result, &head); err != nil {
+			return err
+		}
+		onHead(uint64(head.Number))
+	}
+}
+
+// contextError returns the error of the context when it caused the failure of the socket.
+func contextError(ctx context.Context, err error) error {
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	return err
+}
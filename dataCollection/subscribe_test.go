@@ -0,0 +1,93 @@
+package dataCollection
+
+import (
+	"context"
+	"fmt"
+	"github.com/go-test/deep"
+	"github.com/gorilla/websocket"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newNewHeadsServer returns the local WebSocket endpoint that accepts the newHeads subscription,
+// announces the given heads, one of them on an unrelated subscription, and then keeps the socket open
+// until the client goes away or drops it when hangUp is set.
+func newNewHeadsServer(heads []string, hangUp bool) *httptest.Server {
+	upgrader := websocket.Upgrader{}
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		var req request
+		if err := conn.ReadJSON(&req); err != nil || req.Method != "eth_subscribe" || req.Params[0] != "newHeads" {
+			return
+		}
+		_ = conn.WriteMessage(websocket.TextMessage, []byte(`{"jsonrpc":"2.0","id":1,"result":"0xcd0c3e8af590364c09d0fa6a1210faf5"}`))
+		_ = conn.WriteMessage(websocket.TextMessage, []byte(`{"jsonrpc":"2.0","method":"eth_subscription","params":{"subscription":"0x1","result":{"number":"0x1"}}}`))
+		for _, head := range heads {
+			_ = conn.WriteMessage(websocket.TextMessage, []byte(fmt.Sprintf(
+				`{"jsonrpc":"2.0","method":"eth_subscription","params":{"subscription":"0xcd0c3e8af590364c09d0fa6a1210faf5","result":{"number":"%s","hash":"0x0"}}}`, head)))
+		}
+		if hangUp {
+			return
+		}
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}))
+}
+
+func wsURL(ts *httptest.Server) string {
+	return "ws" + strings.TrimPrefix(ts.URL, "http")
+}
+
+func TestSubscribeNewHeads(t *testing.T) {
+	ts := newNewHeadsServer([]string{"0x7fb25b", "0x7fb25c"}, true)
+	defer ts.Close()
+
+	var got []uint64
+	err := SubscribeNewHeads(context.Background(), wsURL(ts), func(blockNumber uint64) {
+		got = append(got, blockNumber)
+	})
+	if err == nil {
+		t.Errorf("Expected: the error of the dropped socket got : nil")
+	}
+	if diff := deep.Equal([]uint64{0x7fb25b, 0x7fb25c}, got); diff != nil {
+		t.Error(diff)
+	}
+}
+
+func TestSubscribeNewHeads_canceled(t *testing.T) {
+	ts := newNewHeadsServer([]string{"0x7fb25b"}, false)
+	defer ts.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		done <- SubscribeNewHeads(ctx, wsURL(ts), func(uint64) { cancel() })
+	}()
+	select {
+	case err := <-done:
+		if err != context.Canceled {
+			t.Errorf("Expected: %v got : %v", context.Canceled, err)
+		}
+	case <-time.After(time.Second):
+		t.Error("Expected: the subscription to stop with the context got : still running")
+	}
+}
+
+func TestSubscribeNewHeads_unreachable(t *testing.T) {
+	ts := httptest.NewServer(http.NotFoundHandler())
+	defer ts.Close()
+
+	if err := SubscribeNewHeads(context.Background(), wsURL(ts), func(uint64) {}); err == nil {
+		t.Errorf("Expected: the error of the handshake got : nil")
+	}
+}
@@ -0,0 +1,352 @@
+package dataCollection
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/LucaPaterlini/infura/config"
+	"log"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ErrTrackerStarted is returned when starting a HeadTracker that is already running.
+var ErrTrackerStarted = errors.New("head tracker already started")
+
+// HeadTracker keeps track of the last block of the chain, subscribing to the newHeads of the WebSocket endpoint
+// when it has one and polling the last block through the Client while the subscription is down or missing.
+// Nothing is tracked until Start is called.
+type HeadTracker struct {
+	client *Client
+	wsURL  string
+	// PollInterval is the interval between two polls of the last block while there is no subscription.
+	PollInterval time.Duration
+	// ReconnectInterval is the wait before subscribing again after the socket dropped.
+	ReconnectInterval time.Duration
+	// Timeout is the timeout of each poll of the last block.
+	Timeout time.Duration
+	// ReorgDepth is the number of last blocks whose hashes are tracked, once OnReorg is called, to detect the reorgs.
+	ReorgDepth uint64
+
+	latest    uint64
+	mtx       sync.Mutex
+	callbacks []func(blockNumber uint64)
+	// reorgCallbacks are notified of the reorgs detected through the hashes of the last blocks,
+	// that are only accessed by the tracking
+	reorgCallbacks []func(reorg Reorg)
+	hashes         map[uint64]string
+	reorgs         uint64
+	// tags contains the blocks safe and finalized resolved at the last block
+	tags map[string]taggedBlock
+	// finalized is the highest finalized block resolved, that never moves back
+	finalized uint64
+	cancel    context.CancelFunc
+	done      chan struct{}
+}
+
+// Reorg reports the blocks replaced by a reorganization of the chain, the orphaned ones,
+// with their numbers in ascending order and their old hashes.
+type Reorg struct {
+	Numbers []uint64
+	Hashes  []string
+}
+
+// taggedBlock is the number a tag resolved to when the given block was the last one.
+type taggedBlock struct {
+	latest, number uint64
+}
+
+// NewHeadTracker returns the HeadTracker that subscribes to the newHeads at wsURL, polling the last block
+// every config.HeadPollTime while the socket is down, or that only polls it every config.CacheUpdateLastBlockTime
+// when wsURL is empty.
+func NewHeadTracker(client *Client, wsURL string) *HeadTracker {
+	t := &HeadTracker{
+		client:            client,
+		wsURL:             wsURL,
+		PollInterval:      config.HeadPollTime,
+		ReconnectInterval: config.HeadReconnectTime,
+		Timeout:           config.DefaultRequestsTimeout,
+		ReorgDepth:        config.ReorgDepth,
+	}
+	if wsURL == "" {
+		t.PollInterval = config.CacheUpdateLastBlockTime
+	}
+	return t
+}
+
+// Start retrieves the last block and then keeps tracking it in background until the context is done or Stop is called.
+// The error of the first retrieval is returned, the tracking goes on regardless.
+func (t *HeadTracker) Start(ctx context.Context) error {
+	t.mtx.Lock()
+	if t.done != nil {
+		t.mtx.Unlock()
+		return ErrTrackerStarted
+	}
+	ctx, t.cancel = context.WithCancel(ctx)
+	t.done = make(chan struct{})
+	t.mtx.Unlock()
+
+	err := t.poll(ctx)
+	go t.run(ctx)
+	return err
+}
+
+// Stop stops the tracking and waits for it to return, the last block known is kept.
+func (t *HeadTracker) Stop() {
+	t.mtx.Lock()
+	cancel, done := t.cancel, t.done
+	t.mtx.Unlock()
+	if cancel == nil {
+		return
+	}
+	cancel()
+	<-done
+}
+
+// Latest returns the number of the last block known, 0 until the first one is retrieved.
+func (t *HeadTracker) Latest() uint64 {
+	return atomic.LoadUint64(&t.latest)
+}
+
+// Finalized returns the number of the last finalized block resolved, 0 until the finalized tag is resolved.
+func (t *HeadTracker) Finalized() uint64 {
+	return atomic.LoadUint64(&t.finalized)
+}
+
+// Resolve returns the number of the block the tag refers to: latest and pending are derived from the last block,
+// while safe and finalized are requested to the third party api at most once for every new block.
+func (t *HeadTracker) Resolve(ctx context.Context, tag string) (uint64, error) {
+	latest := t.Latest()
+	switch tag {
+	case TagLatest:
+		return latest, nil
+	case TagPending:
+		return latest + 1, nil
+	case TagEarliest:
+		return 0, nil
+	case TagSafe, TagFinalized:
+	default:
+		return 0, fmt.Errorf("%w: unknown block tag %q", ErrInvalidParams, tag)
+	}
+	t.mtx.Lock()
+	tagged, ok := t.tags[tag]
+	t.mtx.Unlock()
+	if ok && tagged.latest == latest {
+		return tagged.number, nil
+	}
+	block, err := t.client.GetBlockByTag(ctx, tag, false)
+	if err != nil {
+		return 0, err
+	}
+	if block == nil {
+		return 0, fmt.Errorf("%w: no %s block", ErrNotFound, tag)
+	}
+	t.mtx.Lock()
+	if t.tags == nil {
+		t.tags = make(map[string]taggedBlock)
+	}
+	t.tags[tag] = taggedBlock{latest: latest, number: uint64(block.Number)}
+	if tag == TagFinalized && uint64(block.Number) > t.finalized {
+		atomic.StoreUint64(&t.finalized, uint64(block.Number))
+	}
+	t.mtx.Unlock()
+	return uint64(block.Number), nil
+}
+
+// OnNewHead registers a callback called with the number of every new last block, in order of registration.
+func (t *HeadTracker) OnNewHead(f func(blockNumber uint64)) {
+	t.mtx.Lock()
+	t.callbacks = append(t.callbacks, f)
+	t.mtx.Unlock()
+}
+
+// OnReorg registers a callback called with every reorg detected, before the new last block is notified,
+// the hashes of the last blocks are only tracked, with an additional request for each head, once it is called.
+func (t *HeadTracker) OnReorg(f func(reorg Reorg)) {
+	t.mtx.Lock()
+	t.reorgCallbacks = append(t.reorgCallbacks, f)
+	t.mtx.Unlock()
+}
+
+// Reorgs returns the number of reorgs detected.
+func (t *HeadTracker) Reorgs() uint64 {
+	return atomic.LoadUint64(&t.reorgs)
+}
+
+// run tracks the last block until the context is done.
+func (t *HeadTracker) run(ctx context.Context) {
+	defer close(t.done)
+	if t.wsURL == "" {
+		t.pollUntil(ctx, nil)
+		return
+	}
+	for ctx.Err() == nil {
+		err := SubscribeNewHeads(ctx, t.wsURL, func(blockNumber uint64) {
+			t.observe(ctx, blockNumber)
+		})
+		if ctx.Err() != nil {
+			return
+		}
+		log.Printf("newHeads subscription to %s dropped, polling the last block: %s", t.wsURL, err)
+		_ = t.poll(ctx)
+		reconnect := time.NewTimer(t.ReconnectInterval)
+		t.pollUntil(ctx, reconnect.C)
+		reconnect.Stop()
+	}
+}
+
+// pollUntil polls the last block every PollInterval until the context is done or the stop channel fires.
+func (t *HeadTracker) pollUntil(ctx context.Context, stop <-chan time.Time) {
+	ticker := time.NewTicker(t.PollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-stop:
+			return
+		case <-ticker.C:
+			_ = t.poll(ctx)
+		}
+	}
+}
+
+// poll retrieves the last block through the client, with the set timeout.
+func (t *HeadTracker) poll(ctx context.Context) error {
+	pollCtx, cancel := context.WithTimeout(ctx, t.Timeout)
+	lastBlock, err := t.client.GetLastBlockNumber(pollCtx)
+	cancel()
+	if err != nil {
+		log.Println(err)
+		return err
+	}
+	t.observe(ctx, lastBlock)
+	return nil
+}
+
+// observe checks the head announced or polled for a reorg, when they are tracked, and then updates the last block.
+func (t *HeadTracker) observe(ctx context.Context, blockNumber uint64) {
+	t.mtx.Lock()
+	callbacks := t.reorgCallbacks
+	t.mtx.Unlock()
+	if len(callbacks) > 0 {
+		if reorg := t.checkReorg(ctx, blockNumber); len(reorg.Numbers) > 0 {
+			atomic.AddUint64(&t.reorgs, 1)
+			log.Printf("reorg of %d blocks from block %d", len(reorg.Numbers), reorg.Numbers[0])
+			for _, f := range callbacks {
+				f(reorg)
+			}
+		}
+	}
+	t.update(blockNumber)
+}
+
+// checkReorg records the hash of the head and walks back its parents until a block whose hash is already known,
+// returning the blocks whose hash changed, with the ones after the head when the head itself changed.
+func (t *HeadTracker) checkReorg(ctx context.Context, blockNumber uint64) Reorg {
+	ctx, cancel := context.WithTimeout(ctx, t.Timeout)
+	defer cancel()
+	head, err := t.client.GetBlock(ctx, blockNumber)
+	if err != nil || head == nil {
+		if err != nil {
+			log.Println(err)
+		}
+		return Reorg{}
+	}
+	orphaned := t.recordHead(blockNumber, head.Hash)
+	t.walkBack(ctx, blockNumber, head.ParentHash, orphaned)
+	t.pruneHashes(blockNumber)
+	return newReorg(orphaned)
+}
+
+// recordHead stores the hash of the head, returning the blocks at its height and after it
+// when the chain got shorter or was replaced at the same height.
+func (t *HeadTracker) recordHead(blockNumber uint64, hash string) map[uint64]string {
+	if t.hashes == nil {
+		t.hashes = make(map[uint64]string)
+	}
+	orphaned := make(map[uint64]string)
+	if known, ok := t.hashes[blockNumber]; ok && known != hash {
+		for number, old := range t.hashes {
+			if number >= blockNumber {
+				orphaned[number] = old
+				delete(t.hashes, number)
+			}
+		}
+	}
+	t.hashes[blockNumber] = hash
+	return orphaned
+}
+
+// walkBack follows the parents of the head down to the oldest block tracked, until one whose hash is already known,
+// adding to orphaned the blocks whose hash changed and requesting the blocks skipped by the heads.
+func (t *HeadTracker) walkBack(ctx context.Context, blockNumber uint64, parent string, orphaned map[uint64]string) {
+	oldest := blockNumber
+	for number := range t.hashes {
+		if number < oldest {
+			oldest = number
+		}
+	}
+	for number := blockNumber; number > oldest; {
+		number--
+		known, ok := t.hashes[number]
+		if ok && known == parent {
+			return
+		}
+		if ok {
+			orphaned[number] = known
+		}
+		block, err := t.client.GetBlock(ctx, number)
+		if err != nil || block == nil {
+			delete(t.hashes, number)
+			return
+		}
+		t.hashes[number], parent = block.Hash, block.ParentHash
+	}
+}
+
+// pruneHashes forgets the blocks deeper than ReorgDepth from the head or from the last block known.
+func (t *HeadTracker) pruneHashes(blockNumber uint64) {
+	latest := t.Latest()
+	if blockNumber > latest {
+		latest = blockNumber
+	}
+	for number := range t.hashes {
+		if number+t.ReorgDepth <= latest {
+			delete(t.hashes, number)
+		}
+	}
+}
+
+// newReorg returns the Reorg of the orphaned blocks, in ascending order.
+func newReorg(orphaned map[uint64]string) (reorg Reorg) {
+	for number := range orphaned {
+		reorg.Numbers = append(reorg.Numbers, number)
+	}
+	sort.Slice(reorg.Numbers, func(i, j int) bool { return reorg.Numbers[i] < reorg.Numbers[j] })
+	for _, number := range reorg.Numbers {
+		reorg.Hashes = append(reorg.Hashes, orphaned[number])
+	}
+	return reorg
+}
+
+// update stores the block as the last one, unless a newer one is already known, and notifies the callbacks.
+func (t *HeadTracker) update(blockNumber uint64) {
+	for {
+		current := atomic.LoadUint64(&t.latest)
+		if blockNumber <= current {
+			return
+		}
+		if atomic.CompareAndSwapUint64(&t.latest, current, blockNumber) {
+			break
+		}
+	}
+	t.mtx.Lock()
+	callbacks := t.callbacks
+	t.mtx.Unlock()
+	for _, f := range callbacks {
+		f(blockNumber)
+	}
+}
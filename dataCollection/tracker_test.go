@@ -0,0 +1,258 @@
+package dataCollection
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/go-test/deep"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestHeadTracker(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(replayUpstreamReplies))
+	defer upstream.Close()
+	ws := newNewHeadsServer([]string{"0x7fb25c", "0x7fb25d"}, true)
+	defer ws.Close()
+
+	client := NewClient(NewHTTPUpstream(upstream.URL))
+	// the polls are counted when the tracker sends them, as the shared upstream call may reach the server later
+	polls := func() uint64 {
+		stats := client.Coalescing()
+		return stats.UpstreamCalls + stats.SavedCalls
+	}
+	heads := NewHeadTracker(client, wsURL(ws))
+	heads.PollInterval = 10 * time.Millisecond
+	heads.ReconnectInterval = time.Hour
+	mtx := sync.Mutex{}
+	var got []uint64
+	heads.OnNewHead(func(blockNumber uint64) {
+		mtx.Lock()
+		got = append(got, blockNumber)
+		mtx.Unlock()
+	})
+	if latest := heads.Latest(); latest != 0 {
+		t.Errorf("Expected: 0 before the start got : %d", latest)
+	}
+	if err := heads.Start(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	// the heads announced by the subscription follow the polled one
+	if !waitFor(func() bool { return heads.Latest() == 0x7fb25d }) {
+		t.Errorf("Expected: %d got : %d", 0x7fb25d, heads.Latest())
+	}
+	// once the socket dropped the last block is polled, without moving it back
+	if !waitFor(func() bool { return polls() >= 3 }) {
+		t.Errorf("Expected: the polling fallback got : %d polls", polls())
+	}
+	if err := heads.Start(context.Background()); err != ErrTrackerStarted {
+		t.Errorf("Expected: %v got : %v", ErrTrackerStarted, err)
+	}
+	heads.Stop()
+	stopped := polls()
+	time.Sleep(50 * time.Millisecond)
+	if polls := polls(); polls != stopped {
+		t.Errorf("Expected: no polls after the stop got : %d", polls-stopped)
+	}
+
+	mtx.Lock()
+	defer mtx.Unlock()
+	if diff := deep.Equal([]uint64{0x7fb25b, 0x7fb25c, 0x7fb25d}, got); diff != nil {
+		t.Error(diff)
+	}
+	if latest := heads.Latest(); latest != 0x7fb25d {
+		t.Errorf("Expected: the last block kept after the stop got : %d", latest)
+	}
+}
+
+func TestHeadTracker_polling(t *testing.T) {
+	upstream := newTestUpstream()
+	defer upstream.Close()
+
+	heads := NewHeadTracker(NewClient(NewHTTPUpstream(upstream.URL)), "")
+	ctx, cancel := context.WithCancel(context.Background())
+	if err := heads.Start(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if latest := heads.Latest(); latest != 0x7fb25b {
+		t.Errorf("Expected: %d got : %d", 0x7fb25b, latest)
+	}
+	// the tracking stops with the context as well
+	cancel()
+	done := make(chan struct{})
+	go func() {
+		heads.Stop()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Error("Expected: the tracker to stop got : still running")
+	}
+}
+
+func TestHeadTracker_Resolve(t *testing.T) {
+	var calls int64
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt64(&calls, 1)
+		replayUpstreamReplies(w, r)
+	}))
+	defer upstream.Close()
+	heads := NewHeadTracker(NewClient(NewHTTPUpstream(upstream.URL)), "")
+	if err := heads.Start(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	defer heads.Stop()
+	if got := heads.Finalized(); got != 0 {
+		t.Errorf("Finalized Expected: 0 got : %d", got)
+	}
+
+	for _, tc := range []struct {
+		tag           string
+		expected      uint64
+		expectedError error
+	}{
+		{tag: TagLatest, expected: 0x7fb25b},
+		{tag: TagPending, expected: 0x7fb25c},
+		{tag: TagEarliest, expected: 0},
+		{tag: TagFinalized, expected: 0x7fb23b},
+		{tag: TagFinalized, expected: 0x7fb23b},
+		{tag: TagSafe, expectedError: ErrNotFound},
+		{tag: "newest", expectedError: ErrInvalidParams},
+	} {
+		got, err := heads.Resolve(context.Background(), tc.tag)
+		if !errors.Is(err, tc.expectedError) || got != tc.expected {
+			t.Errorf("Resolve(%s) Expected: %d %v got : %d %v", tc.tag, tc.expected, tc.expectedError, got, err)
+		}
+	}
+	if got := heads.Finalized(); got != 0x7fb23b {
+		t.Errorf("Finalized Expected: %d got : %d", 0x7fb23b, got)
+	}
+	// the first poll, the finalized block resolved once for the last block and the safe one not found
+	if calls := atomic.LoadInt64(&calls); calls != 3 {
+		t.Errorf("Expected: 3 calls got : %d", calls)
+	}
+}
+
+// chainUpstream is the Upstream that answers eth_getBlockByNumber with the hash and the parent hash of its blocks,
+// that the tests replace to simulate the reorgs.
+type chainUpstream struct {
+	mtx    sync.Mutex
+	blocks map[uint64][2]string
+}
+
+func (c *chainUpstream) set(blockNumber uint64, hash, parentHash string) {
+	c.mtx.Lock()
+	if hash == "" {
+		delete(c.blocks, blockNumber)
+	} else {
+		c.blocks[blockNumber] = [2]string{hash, parentHash}
+	}
+	c.mtx.Unlock()
+}
+
+func (c *chainUpstream) Post(ctx context.Context, jsonStr []byte) (int, map[string][]string, []byte, error) {
+	var req struct {
+		Method string
+		Params []json.RawMessage
+	}
+	if err := json.Unmarshal(jsonStr, &req); err == nil && req.Method == "eth_blockNumber" {
+		// the head is the highest block of the chain
+		c.mtx.Lock()
+		var head uint64
+		for blockNumber := range c.blocks {
+			if blockNumber > head {
+				head = blockNumber
+			}
+		}
+		c.mtx.Unlock()
+		return http.StatusOK, nil, []byte(fmt.Sprintf(`{"jsonrpc":"2.0","id":1,"result":"0x%x"}`, head)), nil
+	}
+	var blockNumber Uint64
+	if err := json.Unmarshal(jsonStr, &req); err != nil || len(req.Params) == 0 || json.Unmarshal(req.Params[0], &blockNumber) != nil {
+		return http.StatusBadRequest, nil, nil, nil
+	}
+	c.mtx.Lock()
+	block, ok := c.blocks[uint64(blockNumber)]
+	c.mtx.Unlock()
+	if !ok {
+		return http.StatusOK, nil, []byte(`{"jsonrpc":"2.0","id":1,"result":null}`), nil
+	}
+	return http.StatusOK, nil, []byte(fmt.Sprintf(`{"jsonrpc":"2.0","id":1,"result":{"number":"0x%x","hash":"%s","parentHash":"%s","transactions":[]}}`,
+		uint64(blockNumber), block[0], block[1])), nil
+}
+
+func TestHeadTracker_reorg(t *testing.T) {
+	upstream := &chainUpstream{blocks: map[uint64][2]string{100: {"0xa100", "0xa99"}, 101: {"0xa101", "0xa100"}, 102: {"0xa102", "0xa101"}, 103: {"0xa103", "0xa102"}}}
+	heads := NewHeadTracker(NewClient(upstream), "")
+	var reorgs []Reorg
+	heads.OnReorg(func(reorg Reorg) {
+		reorgs = append(reorgs, reorg)
+	})
+	ctx := context.Background()
+	for blockNumber := uint64(100); blockNumber <= 103; blockNumber++ {
+		heads.observe(ctx, blockNumber)
+	}
+
+	// the blocks 102 and 103 are replaced by the chain of the new head 104
+	upstream.set(102, "0xb102", "0xa101")
+	upstream.set(103, "0xb103", "0xb102")
+	upstream.set(104, "0xb104", "0xb103")
+	heads.observe(ctx, 104)
+	// the head is replaced at the same height
+	upstream.set(104, "0xc104", "0xb103")
+	heads.observe(ctx, 104)
+	heads.observe(ctx, 104)
+	// the chain gets shorter
+	upstream.set(103, "0xd103", "0xb102")
+	upstream.set(104, "", "")
+	heads.observe(ctx, 103)
+
+	expected := []Reorg{
+		{Numbers: []uint64{102, 103}, Hashes: []string{"0xa102", "0xa103"}},
+		{Numbers: []uint64{104}, Hashes: []string{"0xb104"}},
+		{Numbers: []uint64{103, 104}, Hashes: []string{"0xb103", "0xc104"}},
+	}
+	if diff := deep.Equal(expected, reorgs); diff != nil {
+		t.Error(diff)
+	}
+	if got := heads.Reorgs(); got != 3 {
+		t.Errorf("Expected: 3 reorgs got : %d", got)
+	}
+	// the last block never moves back
+	if latest := heads.Latest(); latest != 104 {
+		t.Errorf("Expected: 104 got : %d", latest)
+	}
+}
+
+func TestHeadTracker_pollReorg(t *testing.T) {
+	upstream := &chainUpstream{blocks: map[uint64][2]string{100: {"0xa100", "0xa99"}, 101: {"0xa101", "0xa100"}}}
+	heads := NewHeadTracker(NewClient(upstream), "")
+	heads.PollInterval = 5 * time.Millisecond
+	reorgs := make(chan Reorg, 1)
+	heads.OnReorg(func(reorg Reorg) {
+		reorgs <- reorg
+	})
+	if err := heads.Start(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	defer heads.Stop()
+
+	// the block 101 polled is replaced at the same height
+	time.Sleep(20 * time.Millisecond)
+	upstream.set(101, "0xb101", "0xa100")
+	select {
+	case reorg := <-reorgs:
+		expected := Reorg{Numbers: []uint64{101}, Hashes: []string{"0xa101"}}
+		if diff := deep.Equal(expected, reorg); diff != nil {
+			t.Error(diff)
+		}
+	case <-time.After(time.Second):
+		t.Error("Expected: the reorg detected by the polling got : none")
+	}
+}
@@ -0,0 +1,72 @@
+package dataCollection
+
+import (
+	"bytes"
+	"context"
+	"github.com/LucaPaterlini/infura/config"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"time"
+)
+
+// Upstream is the JSON-RPC endpoint the package collects the data from,
+// it can be INFURA, a different provider, a self hosted node or a test double.
+type Upstream interface {
+	// Post sends the json encoded request to the endpoint and returns the content of the http response,
+	// giving up as soon as the context is done.
+	Post(ctx context.Context, jsonStr []byte) (statusCode int, header map[string][]string, body []byte, err error)
+}
+
+// sharedHTTPClient is the http client shared by every HTTPUpstream, so that the connections
+// to the upstreams are kept alive and reused across the requests.
+var sharedHTTPClient = &http.Client{
+	Transport: &http.Transport{
+		Proxy: http.ProxyFromEnvironment,
+		DialContext: (&net.Dialer{
+			Timeout:   5 * time.Second,
+			KeepAlive: 30 * time.Second,
+		}).DialContext,
+		ForceAttemptHTTP2:     true,
+		MaxIdleConns:          config.UpstreamMaxIdleConns,
+		MaxIdleConnsPerHost:   config.UpstreamMaxIdleConnsPerHost,
+		IdleConnTimeout:       90 * time.Second,
+		TLSHandshakeTimeout:   10 * time.Second,
+		ExpectContinueTimeout: time.Second,
+	},
+}
+
+// HTTPUpstream is the Upstream that reaches a JSON-RPC endpoint over http.
+type HTTPUpstream struct {
+	URL    string
+	Client *http.Client
+}
+
+// NewHTTPUpstream returns the Upstream that sends the requests to the JSON-RPC endpoint at url,
+// using the pooled connections of the shared http client.
+func NewHTTPUpstream(url string) *HTTPUpstream {
+	return &HTTPUpstream{URL: url, Client: sharedHTTPClient}
+}
+
+// Post call the third party api bound to the context, and returns the content of the http response.
+func (u *HTTPUpstream) Post(ctx context.Context, jsonStr []byte) (statusCode int, header map[string][]string, body []byte, err error) {
+	var req *http.Request
+	req, err = http.NewRequestWithContext(ctx, http.MethodPost, u.URL, bytes.NewBuffer(jsonStr))
+	if err != nil {
+		return
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	var resp *http.Response
+	resp, err = u.Client.Do(req)
+	if err != nil {
+		return
+	}
+	body, err = ioutil.ReadAll(resp.Body)
+
+	_ = resp.Body.Close()
+
+	statusCode = resp.StatusCode
+	header = resp.Header
+	return
+}
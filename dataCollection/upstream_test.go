@@ -0,0 +1,101 @@
+package dataCollection
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/LucaPaterlini/infura/config"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// newTestUpstream starts a local stand-in of the third party api that replays upstreamReplies.
+func newTestUpstream() *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(replayUpstreamReplies))
+}
+
+// replayUpstreamReplies answers the JSON-RPC requests with the recorded upstreamReplies.
+func replayUpstreamReplies(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Method string
+		Params json.RawMessage
+		ID     json.RawMessage
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	params, _ := json.Marshal(req.Params)
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Vary", "Origin")
+	if reply, ok := upstreamReplies[req.Method+string(params)]; ok {
+		_, _ = w.Write([]byte(reply))
+		return
+	}
+	_, _ = fmt.Fprintf(w, `{"jsonrpc":"2.0","id":%s,"result":null}`, req.ID)
+}
+
+func TestHTTPUpstream_Post(t *testing.T) {
+	ts := newTestUpstream()
+	defer ts.Close()
+
+	gotStatus, _, gotBody, gotErr := NewHTTPUpstream(ts.URL).Post(context.Background(), []byte(`{"jsonrpc":"2.0","method":"eth_blockNumber","params": [],"id":1}`))
+	if gotErr != nil {
+		t.Fatalf("unexpected error \n%s", gotErr.Error())
+	}
+	if gotStatus != http.StatusOK || string(gotBody) != upstreamReplies["eth_blockNumber[]"] {
+		t.Errorf("Expected: %d %s\nGot     : %d %s", http.StatusOK, upstreamReplies["eth_blockNumber[]"], gotStatus, gotBody)
+	}
+
+	if _, _, _, gotErr = NewHTTPUpstream("http://127.0.0.1:0").Post(context.Background(), nil); gotErr == nil {
+		t.Error("expected error for an unreachable upstream")
+	}
+}
+
+var errTest = errors.New("test error")
+
+func TestHTTPUpstream_Post_canceled(t *testing.T) {
+	// the upstream only answers when the request is abandoned by the client
+	canceled := make(chan struct{})
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = ioutil.ReadAll(r.Body)
+		<-r.Context().Done()
+		close(canceled)
+	}))
+	defer ts.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		cancel()
+	}()
+	f := NewFailoverUpstream(ts.URL)
+	_, err := NewClient(f).GetLastBlockNumber(ctx)
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected: %v, got : %v", context.Canceled, err)
+	}
+	select {
+	case <-canceled:
+	case <-time.After(time.Second):
+		t.Error("Expected the cancellation to reach the upstream")
+	}
+	// the cancellation is not a failure of the upstream
+	if health := f.Health(); !health[0].Healthy || health[0].Breaker != "closed" {
+		t.Errorf("Unexpected health %+v", health)
+	}
+}
+
+func TestNewHTTPUpstream(t *testing.T) {
+	u := NewHTTPUpstream("http://127.0.0.1")
+	if u.Client != NewHTTPUpstream("http://127.0.0.2").Client {
+		t.Error("Expected the upstreams to share the same http client")
+	}
+	transport := u.Client.Transport.(*http.Transport)
+	if !transport.ForceAttemptHTTP2 || transport.MaxIdleConnsPerHost != config.UpstreamMaxIdleConnsPerHost {
+		t.Errorf("Unexpected transport %+v", transport)
+	}
+}
@@ -3,9 +3,13 @@ module github.com/LucaPaterlini/infura
 go 1.13
 
 require (
+	github.com/alicebob/miniredis/v2 v2.30.4
+	github.com/go-redis/redis/v8 v8.11.4
 	github.com/go-test/deep v1.0.3
 	github.com/gorilla/handlers v1.4.2
 	github.com/gorilla/mux v1.7.3
-	github.com/victorspringer/http-cache v0.0.0-20190721184638-fe78e97af707
+	github.com/gorilla/websocket v1.4.2
+	go.etcd.io/bbolt v1.3.6
+	golang.org/x/crypto v0.0.0-20211117183948-ae814b36b871
 	golang.org/x/time v0.0.0-20190308202827-9d24e82272b4
 )
@@ -1,82 +1,218 @@
 package main
 
 import (
+	"context"
 	"flag"
+	"fmt"
 	"github.com/LucaPaterlini/infura/API"
 	"github.com/LucaPaterlini/infura/config"
+	"github.com/LucaPaterlini/infura/dataCollection"
+	"github.com/LucaPaterlini/infura/middlewares/cache"
 	"github.com/LucaPaterlini/infura/middlewares/limit"
 	"github.com/LucaPaterlini/infura/middlewares/logger"
 	"github.com/gorilla/handlers"
 	"github.com/gorilla/mux"
-	cache "github.com/victorspringer/http-cache"
-	"github.com/victorspringer/http-cache/adapter/memory"
+	"golang.org/x/time/rate"
 	"log"
 	"net/http"
 	"os"
+	"runtime/debug"
+	"strings"
 	"time"
 )
 
 var limiterActive = flag.Bool("limiter", false, "activate limiter filter")
+var chainsPath = flag.String("chains", "",
+	"JSON file of the chains to serve in addition to mainnet, Sepolia and Holesky, or replacing them by name")
+var upstreamURLs = flag.String("upstream", config.FullMainNetPath,
+	"comma separated urls of the JSON-RPC endpoints to collect the data of the default chain from, in order of preference")
+var newHeadsURL = flag.String("ws", config.FullMainNetWSPath,
+	"url of the JSON-RPC WebSocket endpoint to subscribe to the new blocks of the default chain, empty to poll the last block instead")
+var cacheBackend = flag.String("cache", "memory",
+	"backend of the cache of the responses: memory, or redis to share it with the other replicas")
+var redisURL = flag.String("redis", config.CacheRedisURL, "url of the Redis server of the redis cache backend")
+var diskCachePath = flag.String("disk-cache", config.CacheDiskPath,
+	"file of the cache on disk of the finalized data kept across the restarts, empty to cache only in memory")
+var rpcMethods = flag.String("rpc-methods", config.RPCAllowedMethods,
+	"comma separated JSON-RPC methods served by the rpc endpoint")
 
 func main() {
 	flag.Parse()
-	// declaring the routes
-	router := mux.NewRouter().PathPrefix("/v1/").Subrouter()
-	router.HandleFunc("/block/{blockId:[0-9]+}", API.GetBlockHandler).Methods(http.MethodGet)
-	router.HandleFunc("/tx/{blockId:[0-9]+}/{txId:[0-9]+}", API.GetTransactionHandler).Methods(http.MethodGet)
+	// tune the garbage collector for the caching workload
+	debug.SetGCPercent(10)
 
-	// allowing cors
-	router.Use(mux.CORSMethodMiddleware(router))
-	// prepare the limiter middleware
-	accessLimit := limit.Visitors{
-		CleanupRefreshTime: 10 * time.Second,
-		CleanupExpiry:      time.Minute,
-		// allow 10 new request for each user each second
-		R: 10,
-		B: 15,
-	}
-
-	// allocate the memory for caching (
-
-	memcached, err := memory.NewAdapter(
-		memory.AdapterWithAlgorithm(memory.LRU),
-		memory.AdapterWithCapacity(config.CacheSize),
-	)
+	registry, err := config.LoadRegistry(*chainsPath)
 	if err != nil {
 		log.Println(err)
 		os.Exit(1)
 	}
+	// the endpoints given by flag replace the ones of the default chain
+	flag.Visit(func(f *flag.Flag) {
+		for i := range registry.Chains {
+			switch {
+			case registry.Chains[i].Name != registry.Default:
+			case f.Name == "upstream":
+				registry.Chains[i].Upstreams = strings.Split(*upstreamURLs, ",")
+			case f.Name == "ws":
+				registry.Chains[i].WS = *newHeadsURL
+			}
+		}
+	})
 
-	cacheClient, err := cache.NewClient(
-		cache.ClientWithAdapter(memcached),
-		cache.ClientWithTTL(config.CacheExpireTime),
-		cache.ClientWithRefreshKey("opn"),
-	)
+	// the cache is shared by all the chains, each in its own namespace
+	adapter, err := newAdapter(*cacheBackend)
 	if err != nil {
 		log.Println(err)
 		os.Exit(1)
 	}
-	// add handler compression
-	handler := handlers.CompressHandler(router)
-
-	// add Panic Logger middleware
-	handler = logger.LogRequestPanic(handler)
+	// the data that can not change anymore is also kept on disk, so that it survives the restarts
+	if *diskCachePath != "" {
+		disk, err := cache.NewDiskAdapter(*diskCachePath, config.CacheDiskSize)
+		if err != nil {
+			log.Println(err)
+			os.Exit(1)
+		}
+		defer disk.Close()
+		adapter = cache.Tiered(adapter, disk, config.CacheDiskMinTime)
+	}
 
-	// add http response caching
-	handler = cacheClient.Middleware(handler)
+	// each chain is served under /v1/{chain}/, the default one also under /v1/
+	root := mux.NewRouter()
+	var defaultHandler http.Handler
+	for _, chain := range registry.Chains {
+		name, adapter := chain.Name, cache.Namespace(adapter, chain.Name)
+		cacheClient := cache.NewClient(adapter, config.CacheExpireTime, "opn")
+		api, heads := newChain(chain, adapter)
+		// the responses and the blocks read from the blocks replaced by a reorg are evicted,
+		// while the responses of the blocks too deep to be reorged are not tracked anymore
+		heads.OnReorg(func(reorg dataCollection.Reorg) {
+			api.Evict(reorg)
+			log.Printf("%s: evicted %d responses of the reorged blocks", name, cacheClient.Evict(reorg.Numbers...))
+		})
+		heads.OnNewHead(func(blockNumber uint64) {
+			if blockNumber > heads.ReorgDepth {
+				cacheClient.Forget(blockNumber - heads.ReorgDepth)
+			}
+			// the blocks up to the finalized one are cached indefinitely even when they are not deep enough
+			go func() {
+				ctx, cancel := context.WithTimeout(context.Background(), config.DefaultRequestsTimeout)
+				defer cancel()
+				_, _ = heads.Resolve(ctx, dataCollection.TagFinalized)
+			}()
+		})
+		if err := heads.Start(context.Background()); err != nil {
+			log.Println(name, err)
+		}
+		defer heads.Stop()
+		prefixes := []string{"/v1/" + chain.Name + "/"}
+		if chain.Name == registry.Default {
+			prefixes = append(prefixes, "/v1/")
+		}
+		handler := chainHandler(api, chain, cacheClient, prefixes)
+		root.PathPrefix(prefixes[0]).Handler(handler)
+		if chain.Name == registry.Default {
+			defaultHandler = handler
+		}
+	}
+	root.PathPrefix("/").Handler(defaultHandler)
 
 	// add Request Logger middleware
-	handler = logger.LogRequest(handler)
+	handler := logger.LogRequest(root)
 
 	srv := &http.Server{
 		Addr:         config.DefaultAddr,
 		WriteTimeout: time.Minute * 10,
 		ReadTimeout:  time.Second * 15,
 		IdleTimeout:  time.Second * 60,
-		// log the requests compress the handler (its safe as its now no user input data or tls),
-		// limit the access for each user
-		Handler: accessLimit.Limit(handler, *limiterActive),
+		// log the requests, each chain compresses its handler (its safe as its now no user input data or tls)
+		// and limits the access for each user
+		Handler: handler,
 	}
 
 	log.Fatal(srv.ListenAndServe())
 }
+
+// newAdapter returns the adapter of the cache backend, the memory of the process or the Redis server
+// shared by the replicas, with the keys prefixed by config.CacheRedisNamespace.
+func newAdapter(backend string) (cache.Adapter, error) {
+	switch backend {
+	case "memory":
+		// allocate the memory for caching
+		return cache.NewMemoryAdapter(config.CacheSize)
+	case "redis":
+		redisAdapter, err := cache.NewRedisAdapter(*redisURL, config.CacheRedisPoolSize, config.CacheRedisTimeout)
+		if err != nil {
+			return nil, fmt.Errorf("redis cache %s: %s", *redisURL, err)
+		}
+		return cache.Namespace(redisAdapter, config.CacheRedisNamespace), nil
+	default:
+		return nil, fmt.Errorf("unknown cache backend %q", backend)
+	}
+}
+
+// newChain probes the upstreams of the chain and injects them, with the tracker of its last block, in the handlers,
+// the returned HeadTracker has to be started and stopped by the caller.
+func newChain(chain config.Chain, store dataCollection.ChunkStore) (*API.Handler, *dataCollection.HeadTracker) {
+	upstream := dataCollection.NewFailoverUpstream(chain.Upstreams...)
+	upstream.HealthCheck(context.Background(), config.UpstreamHealthCheckTime, config.DefaultRequestsTimeout)
+	client := dataCollection.NewClient(upstream)
+	heads := dataCollection.NewHeadTracker(client, chain.WS)
+	if chain.BlockTime > 0 {
+		heads.PollInterval = time.Duration(chain.BlockTime)
+	}
+	api := API.NewHandler(client, heads, store)
+	api.ConfirmationDepth = chain.ConfirmationDepth
+	api.AllowRPCMethods(strings.Split(*rpcMethods, ",")...)
+	return api, heads
+}
+
+// chainHandler returns the handler of the routes of the chain under each of the prefixes,
+// caching the responses with the cache client and limiting the requests of each user with the budget of the chain.
+func chainHandler(api *API.Handler, chain config.Chain, cacheClient *cache.Client, prefixes []string) http.Handler {
+	// declaring the routes
+	router := mux.NewRouter()
+	root := mux.NewRouter()
+	for _, prefix := range prefixes {
+		sub := router.PathPrefix(prefix).Subrouter()
+		sub.HandleFunc("/block/{blockId:"+API.BlockIDPattern+"}", api.GetBlockHandler).Methods(http.MethodGet)
+		sub.HandleFunc("/tx/{blockId:"+API.BlockIDPattern+"}/{txId:[0-9]+}", api.GetTransactionHandler).Methods(http.MethodGet)
+		sub.HandleFunc("/tx/hash/{txHash}", api.GetTransactionByHashHandler).Methods(http.MethodGet)
+		sub.HandleFunc("/tx/{txHash}/receipt", api.GetTransactionReceiptHandler).Methods(http.MethodGet)
+		sub.HandleFunc("/block/{blockId:"+API.BlockIDPattern+"}/receipts", api.GetBlockReceiptsHandler).Methods(http.MethodGet)
+		sub.HandleFunc("/block/hash/{blockHash}", api.GetBlockByHashHandler).Methods(http.MethodGet)
+		sub.HandleFunc("/logs", api.GetLogsHandler).Methods(http.MethodGet)
+		sub.HandleFunc("/account/{address}/balance", api.GetBalanceHandler).Methods(http.MethodGet)
+		sub.HandleFunc("/account/{address}/nonce", api.GetNonceHandler).Methods(http.MethodGet)
+		sub.HandleFunc("/account/{address}/code", api.GetCodeHandler).Methods(http.MethodGet)
+		sub.HandleFunc("/account/{address}/storage/{slot}", api.GetStorageHandler).Methods(http.MethodGet)
+		sub.HandleFunc("/call", api.CallHandler).Methods(http.MethodPost)
+		sub.HandleFunc("/rpc", api.RPCHandler).Methods(http.MethodPost)
+		// allowing cors
+		sub.Use(mux.CORSMethodMiddleware(sub))
+
+		// the status is served outside of the cache to always be up to date
+		root.HandleFunc(prefix+"status", api.StatusHandler).Methods(http.MethodGet)
+		// the ranges of blocks are streamed outside of the cache, that would buffer them, as their blocks are cached one by one
+		root.HandleFunc(prefix+"blocks", api.GetBlocksHandler).Methods(http.MethodGet)
+	}
+
+	// add Panic Logger middleware
+	handler := logger.LogRequestPanic(router)
+
+	// add http response caching
+	handler = cacheClient.Middleware(handler)
+
+	// add handler compression, outside of the cache that stores the responses uncompressed
+	// so that each client gets the encoding it accepts
+	handler = handlers.CompressHandler(handler)
+	root.PathPrefix("/").Handler(handler)
+
+	// prepare the limiter middleware
+	accessLimit := &limit.Visitors{
+		CleanupRefreshTime: 10 * time.Second,
+		CleanupExpiry:      time.Minute,
+		R:                  rate.Limit(chain.RateLimit),
+		B:                  chain.RateBurst,
+	}
+	return accessLimit.Limit(root, *limiterActive)
+}
@@ -0,0 +1,246 @@
+// Package cache provides the http response caching middleware, the lifetime of each response is set by its handler.
+package cache
+
+import (
+	"bytes"
+	"encoding/gob"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Adapter is the storage of the cached responses.
+type Adapter interface {
+	// Get retrieves the cached response by a given key, reporting whether it exists.
+	Get(key string) ([]byte, bool)
+	// Set caches a response for a given key until an expiration date.
+	Set(key string, response []byte, expiration time.Time)
+	// Release frees the cache for a given key.
+	Release(key string)
+}
+
+// MultiGetter is the Adapter able to retrieve several responses with a single round trip, such as the RedisAdapter.
+type MultiGetter interface {
+	// GetMulti retrieves the cached responses by the given keys, nil for the missing ones.
+	GetMulti(keys ...string) [][]byte
+}
+
+// GetMulti retrieves the cached responses by the given keys from the adapter, nil for the missing ones,
+// with a single round trip when the adapter is a MultiGetter and one by one otherwise.
+func GetMulti(adapter Adapter, keys ...string) [][]byte {
+	if getter, ok := adapter.(MultiGetter); ok {
+		return getter.GetMulti(keys...)
+	}
+	responses := make([][]byte, len(keys))
+	for i, key := range keys {
+		if response, ok := adapter.Get(key); ok {
+			responses[i] = response
+		}
+	}
+	return responses
+}
+
+// Response is the cached http response.
+type Response struct {
+	StatusCode int
+	Header     http.Header
+	Value      []byte
+	Expiration time.Time
+}
+
+// Bytes encodes the response to be stored by an Adapter.
+func (r Response) Bytes() []byte {
+	var b bytes.Buffer
+	_ = gob.NewEncoder(&b).Encode(&r)
+	return b.Bytes()
+}
+
+// BytesToResponse decodes the response stored by an Adapter.
+func BytesToResponse(b []byte) (r Response, err error) {
+	err = gob.NewDecoder(bytes.NewReader(b)).Decode(&r)
+	return
+}
+
+// Client is the http response caching middleware, the successful responses of the GET requests are cached
+// for the max-age of their Cache-Control header, or for the default ttl when they do not set one,
+// the responses marked no-store or varying by the request headers, such as the compressed ones, are never cached.
+type Client struct {
+	adapter    Adapter
+	ttl        time.Duration
+	refreshKey string
+
+	mtx sync.Mutex
+	// blocks indexes the cached responses by the blocks they were read from, down to the floor
+	blocks []blockEntry
+	floor  uint64
+}
+
+// The headers of the responses reporting the block, or the range of blocks, they were read from,
+// the responses are evicted when those blocks are replaced by a reorg.
+const (
+	BlockNumberHeader = "X-Block-Number"
+	BlockRangeHeader  = "X-Block-Range"
+)
+
+// blockEntry is the key of a cached response read from the blocks from-to.
+type blockEntry struct {
+	from, to uint64
+	key      string
+}
+
+// NewClient returns the Client that caches the responses in the adapter, for the ttl when their handler does not set it,
+// the requests with the refreshKey query param bypass and refresh the cached response.
+func NewClient(adapter Adapter, ttl time.Duration, refreshKey string) *Client {
+	return &Client{adapter: adapter, ttl: ttl, refreshKey: refreshKey}
+}
+
+// Middleware is the http response caching middleware handler.
+func (c *Client) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			next.ServeHTTP(w, r)
+			return
+		}
+		params := r.URL.Query()
+		_, refresh := params[c.refreshKey]
+		if refresh {
+			delete(params, c.refreshKey)
+			r.URL.RawQuery = params.Encode()
+		}
+		key := Key(r.URL)
+		if refresh {
+			c.adapter.Release(key)
+		} else if b, ok := c.adapter.Get(key); ok {
+			if response, err := BytesToResponse(b); err == nil && response.Expiration.After(time.Now()) {
+				write(w, response.StatusCode, response.Header, response.Value)
+				return
+			}
+			c.adapter.Release(key)
+		}
+
+		rec := httptest.NewRecorder()
+		next.ServeHTTP(rec, r)
+		result := rec.Result()
+		// the responses varying by the request headers are not cached, as the key is the url only
+		if ttl, ok := TTL(result.Header, c.ttl); ok && result.StatusCode < http.StatusBadRequest && result.Header.Get("Vary") == "" {
+			response := Response{
+				StatusCode: result.StatusCode,
+				Header:     result.Header,
+				Value:      rec.Body.Bytes(),
+				Expiration: time.Now().Add(ttl),
+			}
+			c.adapter.Set(key, response.Bytes(), response.Expiration)
+			c.index(key, result.Header)
+		}
+		write(w, result.StatusCode, result.Header, rec.Body.Bytes())
+	})
+}
+
+// index records the blocks the cached response was read from, unless they can not be reorged anymore.
+func (c *Client) index(key string, header http.Header) {
+	var from, to uint64
+	var err error
+	if value := header.Get(BlockNumberHeader); value != "" {
+		from, err = strconv.ParseUint(value, 10, 64)
+		to = from
+	} else if value := header.Get(BlockRangeHeader); value != "" {
+		bounds := strings.SplitN(value, "-", 2)
+		if from, err = strconv.ParseUint(bounds[0], 10, 64); err == nil && len(bounds) == 2 {
+			to, err = strconv.ParseUint(bounds[1], 10, 64)
+		}
+	} else {
+		return
+	}
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	if err == nil && to >= c.floor {
+		c.blocks = append(c.blocks, blockEntry{from: from, to: to, key: key})
+	}
+}
+
+// Evict releases the cached responses read from any of the blocks, as they have been replaced by a reorg,
+// and returns how many were released.
+func (c *Client) Evict(blockNumbers ...uint64) int {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	kept := c.blocks[:0]
+	evicted := 0
+	for _, entry := range c.blocks {
+		if entry.contains(blockNumbers) {
+			c.adapter.Release(entry.key)
+			evicted++
+		} else {
+			kept = append(kept, entry)
+		}
+	}
+	c.blocks = kept
+	return evicted
+}
+
+// Forget stops indexing the cached responses read from the blocks before floor, that can not be reorged anymore.
+func (c *Client) Forget(floor uint64) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	c.floor = floor
+	kept := c.blocks[:0]
+	for _, entry := range c.blocks {
+		if entry.to >= floor {
+			kept = append(kept, entry)
+		}
+	}
+	c.blocks = kept
+}
+
+// contains reports if any of the blocks is in the range of the entry.
+func (e blockEntry) contains(blockNumbers []uint64) bool {
+	for _, blockNumber := range blockNumbers {
+		if blockNumber >= e.from && blockNumber <= e.to {
+			return true
+		}
+	}
+	return false
+}
+
+// Key returns the cache key of the url, independent of the order of its query params.
+func Key(u *url.URL) string {
+	params := u.Query()
+	for _, values := range params {
+		sort.Strings(values)
+	}
+	// Encode sorts the params by name
+	key := *u
+	key.RawQuery = params.Encode()
+	return key.RequestURI()
+}
+
+// TTL returns how long a response with the given headers can be cached, the default ttl when they do not set it,
+// it reports false when the response must not be cached.
+func TTL(header http.Header, ttl time.Duration) (time.Duration, bool) {
+	for _, directive := range strings.Split(header.Get("Cache-Control"), ",") {
+		directive = strings.TrimSpace(directive)
+		switch {
+		case directive == "no-store" || directive == "no-cache" || directive == "private":
+			return 0, false
+		case strings.HasPrefix(directive, "max-age="):
+			seconds, err := strconv.Atoi(strings.TrimPrefix(directive, "max-age="))
+			if err != nil || seconds <= 0 {
+				return 0, false
+			}
+			ttl = time.Duration(seconds) * time.Second
+		}
+	}
+	return ttl, true
+}
+
+func write(w http.ResponseWriter, statusCode int, header http.Header, value []byte) {
+	for k, v := range header {
+		w.Header()[k] = v
+	}
+	w.WriteHeader(statusCode)
+	_, _ = w.Write(value)
+}
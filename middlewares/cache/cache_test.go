@@ -0,0 +1,195 @@
+package cache
+
+import (
+	"fmt"
+	"github.com/gorilla/handlers"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func TestTTL(t *testing.T) {
+	for _, tc := range []struct {
+		cacheControl string
+		expectedTTL  time.Duration
+		expectedOK   bool
+	}{
+		{cacheControl: "", expectedTTL: time.Minute, expectedOK: true},
+		{cacheControl: "public, max-age=86400", expectedTTL: 24 * time.Hour, expectedOK: true},
+		{cacheControl: "max-age=12", expectedTTL: 12 * time.Second, expectedOK: true},
+		{cacheControl: "no-store", expectedOK: false},
+		{cacheControl: "public, no-cache", expectedOK: false},
+		{cacheControl: "max-age=0", expectedOK: false},
+		{cacheControl: "max-age=soon", expectedOK: false},
+	} {
+		ttl, ok := TTL(http.Header{"Cache-Control": {tc.cacheControl}}, time.Minute)
+		if ttl != tc.expectedTTL || ok != tc.expectedOK {
+			t.Errorf("Cache-Control %q Expected: %v %t got : %v %t", tc.cacheControl, tc.expectedTTL, tc.expectedOK, ttl, ok)
+		}
+	}
+}
+
+func TestKey(t *testing.T) {
+	a, _ := url.Parse("/v1/blocks?to=12&from=10")
+	b, _ := url.Parse("/v1/blocks?from=10&to=12")
+	if Key(a) != Key(b) {
+		t.Errorf("Expected: the same key got : %s %s", Key(a), Key(b))
+	}
+}
+
+// recordingAdapter is the Adapter that keeps the responses in a map, recording their expiration.
+type recordingAdapter struct {
+	responses   map[string][]byte
+	expirations map[string]time.Time
+}
+
+func newRecordingAdapter() *recordingAdapter {
+	return &recordingAdapter{responses: make(map[string][]byte), expirations: make(map[string]time.Time)}
+}
+
+func (a *recordingAdapter) Get(key string) ([]byte, bool) {
+	response, ok := a.responses[key]
+	return response, ok
+}
+
+func (a *recordingAdapter) Set(key string, response []byte, expiration time.Time) {
+	a.responses[key] = response
+	a.expirations[key] = expiration
+}
+
+func (a *recordingAdapter) Release(key string) {
+	delete(a.responses, key)
+	delete(a.expirations, key)
+}
+
+func TestClient_Middleware(t *testing.T) {
+	calls := 0
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		switch r.URL.Path {
+		case "/immutable":
+			w.Header().Set("Cache-Control", "public, max-age=86400")
+		case "/pending":
+			w.Header().Set("Cache-Control", "no-store")
+		case "/missing":
+			w.WriteHeader(http.StatusNotFound)
+		case "/compressed":
+			w.Header().Set("Vary", "Accept-Encoding")
+		}
+		_, _ = fmt.Fprintf(w, "call %d", calls)
+	})
+	adapter := newRecordingAdapter()
+	cached := NewClient(adapter, time.Minute, "opn").Middleware(handler)
+
+	for _, tc := range []struct {
+		path           string
+		expectedStatus int
+		expectedBody   string
+		expectedTTL    time.Duration
+	}{
+		{path: "/default", expectedStatus: http.StatusOK, expectedBody: "call 1", expectedTTL: time.Minute},
+		{path: "/default", expectedStatus: http.StatusOK, expectedBody: "call 1", expectedTTL: time.Minute},
+		{path: "/default?opn", expectedStatus: http.StatusOK, expectedBody: "call 2", expectedTTL: time.Minute},
+		{path: "/immutable", expectedStatus: http.StatusOK, expectedBody: "call 3", expectedTTL: 24 * time.Hour},
+		{path: "/immutable", expectedStatus: http.StatusOK, expectedBody: "call 3", expectedTTL: 24 * time.Hour},
+		{path: "/pending", expectedStatus: http.StatusOK, expectedBody: "call 4"},
+		{path: "/pending", expectedStatus: http.StatusOK, expectedBody: "call 5"},
+		{path: "/missing", expectedStatus: http.StatusNotFound, expectedBody: "call 6"},
+		{path: "/missing", expectedStatus: http.StatusNotFound, expectedBody: "call 7"},
+		{path: "/compressed", expectedStatus: http.StatusOK, expectedBody: "call 8"},
+		{path: "/compressed", expectedStatus: http.StatusOK, expectedBody: "call 9"},
+	} {
+		gotW := httptest.NewRecorder()
+		cached.ServeHTTP(gotW, httptest.NewRequest(http.MethodGet, tc.path, nil))
+		if gotW.Code != tc.expectedStatus || gotW.Body.String() != tc.expectedBody {
+			t.Errorf("%s Expected: %d %s got : %d %s", tc.path, tc.expectedStatus, tc.expectedBody, gotW.Code, gotW.Body.String())
+		}
+		u, _ := url.Parse(tc.path)
+		key := Key(&url.URL{Path: u.Path})
+		expiration, ok := adapter.expirations[key]
+		if ok != (tc.expectedTTL > 0) {
+			t.Errorf("%s Expected: cached %t got : %t", tc.path, tc.expectedTTL > 0, ok)
+			continue
+		}
+		if ttl := time.Until(expiration); ok && (ttl > tc.expectedTTL || ttl < tc.expectedTTL-time.Second) {
+			t.Errorf("%s Expected: ttl %v got : %v", tc.path, tc.expectedTTL, ttl)
+		}
+	}
+
+	// the expired responses are served again by the handler
+	adapter.Set("/default", Response{StatusCode: http.StatusOK, Value: []byte("stale")}.Bytes(), time.Now().Add(-time.Second))
+	gotW := httptest.NewRecorder()
+	cached.ServeHTTP(gotW, httptest.NewRequest(http.MethodGet, "/default", nil))
+	if gotW.Body.String() != "call 10" {
+		t.Errorf("Expected: call 10 got : %s", gotW.Body.String())
+	}
+}
+
+func TestClient_compression(t *testing.T) {
+	calls := 0
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.Header().Set("Cache-Control", "public, max-age=60")
+		_, _ = fmt.Fprint(w, "block 12")
+	})
+	// the compression outside of the cache encodes the cached response for each client
+	cached := handlers.CompressHandler(NewClient(newRecordingAdapter(), time.Minute, "opn").Middleware(handler))
+	for _, encoding := range []string{"gzip", "", "deflate"} {
+		r := httptest.NewRequest(http.MethodGet, "/block/12", nil)
+		if encoding != "" {
+			r.Header.Set("Accept-Encoding", encoding)
+		}
+		gotW := httptest.NewRecorder()
+		cached.ServeHTTP(gotW, r)
+		if got := gotW.Header().Get("Content-Encoding"); got != encoding {
+			t.Errorf("Expected: encoding %q got : %q", encoding, got)
+		}
+		if encoding == "" && gotW.Body.String() != "block 12" {
+			t.Errorf("Expected: block 12 got : %s", gotW.Body.String())
+		}
+	}
+	if calls != 1 {
+		t.Errorf("Expected: 1 call got : %d", calls)
+	}
+}
+
+func TestClient_Evict(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v1/block/5":
+			w.Header().Set(BlockNumberHeader, "5")
+		case "/v1/block/9":
+			w.Header().Set(BlockNumberHeader, "9")
+		case "/v1/logs":
+			w.Header().Set(BlockRangeHeader, "3-6")
+		}
+		_, _ = w.Write([]byte("ok"))
+	})
+	adapter := newRecordingAdapter()
+	client := NewClient(adapter, time.Minute, "opn")
+	cached := client.Middleware(handler)
+	for _, path := range []string{"/v1/block/5", "/v1/block/9", "/v1/logs", "/v1/status"} {
+		cached.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+
+	// the block and the range of logs read from the reorged block are evicted
+	if evicted := client.Evict(5); evicted != 2 {
+		t.Errorf("Expected: 2 responses evicted got : %d", evicted)
+	}
+	for path, expected := range map[string]bool{"/v1/block/5": false, "/v1/logs": false, "/v1/block/9": true, "/v1/status": true} {
+		if _, ok := adapter.responses[path]; ok != expected {
+			t.Errorf("%s Expected: cached %t got : %t", path, expected, ok)
+		}
+	}
+	// the responses of the blocks before the floor are not tracked anymore
+	client.Forget(10)
+	if evicted := client.Evict(9); evicted != 0 {
+		t.Errorf("Expected: no response evicted got : %d", evicted)
+	}
+	cached.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/v1/block/5", nil))
+	if evicted := client.Evict(5); evicted != 0 {
+		t.Errorf("Expected: no response tracked before the floor got : %d", evicted)
+	}
+}
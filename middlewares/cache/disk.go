@@ -0,0 +1,346 @@
+package cache
+
+import (
+	"encoding/binary"
+	"errors"
+	bolt "go.etcd.io/bbolt"
+	"log"
+	"os"
+	"sync"
+	"time"
+)
+
+var (
+	// responsesBucket maps the keys to the expiration, in unix nanoseconds, followed by the response.
+	responsesBucket = []byte("responses")
+	// expirationsBucket indexes the keys by their expiration followed by the key, in order of eviction.
+	expirationsBucket = []byte("expirations")
+)
+
+// diskMaxPending is the number of writes waiting for the disk after which the new ones are dropped.
+const diskMaxPending = 4096
+
+// DiskAdapter is the Adapter that persists the responses in a bbolt file, so that they survive the restarts,
+// evicting the ones closest to their expiration when their size exceeds its capacity.
+// The writes are queued and committed in the background with a single transaction for all the ones pending,
+// so that the requests never wait for the disk.
+type DiskAdapter struct {
+	mtx      sync.Mutex
+	db       *bolt.DB
+	capacity int
+	size     int
+	// pending are the writes not committed yet by key, read by Get in place of the file
+	pending map[string]diskWrite
+	// flushMtx serializes the commits of the pending writes
+	flushMtx sync.Mutex
+	// seq numbers the writes queued, to tell the ones queued again during a commit
+	seq  uint64
+	wake chan struct{}
+	stop chan struct{}
+	done chan struct{}
+}
+
+// diskWrite is a write waiting for the disk, the release of the key or the response to store.
+type diskWrite struct {
+	response   []byte
+	expiration time.Time
+	release    bool
+	seq        uint64
+}
+
+// NewDiskAdapter opens the DiskAdapter able to store up to capacity bytes of responses in the file at path.
+// At startup the file is checked, and replaced by an empty one when it is corrupted, the expired responses
+// are removed and the file is compacted when the space freed by the evictions is larger than the responses kept.
+func NewDiskAdapter(path string, capacity int) (*DiskAdapter, error) {
+	if capacity <= 0 {
+		return nil, errors.New("disk adapter requires a capacity greater than 0")
+	}
+	a := &DiskAdapter{
+		capacity: capacity,
+		pending:  make(map[string]diskWrite),
+		wake:     make(chan struct{}, 1),
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+	db, err := openChecked(path)
+	if err != nil {
+		log.Printf("discarding the disk cache %s: %s", path, err)
+		if err = os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return nil, err
+		}
+		if db, err = openChecked(path); err != nil {
+			return nil, err
+		}
+	}
+	a.db = db
+	if err = a.load(); err != nil {
+		_ = db.Close()
+		return nil, err
+	}
+	if err = a.compact(path); err != nil {
+		_ = a.db.Close()
+		return nil, err
+	}
+	go a.write()
+	return a, nil
+}
+
+// openChecked opens the bbolt file at path, creating it when missing, and verifies the consistency of its pages.
+func openChecked(path string) (*bolt.DB, error) {
+	db, err := bolt.Open(path, 0600, &bolt.Options{Timeout: time.Second})
+	if err != nil {
+		return nil, err
+	}
+	err = db.View(func(tx *bolt.Tx) error {
+		for err := range tx.Check() {
+			return err
+		}
+		return nil
+	})
+	if err != nil {
+		_ = db.Close()
+		return nil, err
+	}
+	return db, nil
+}
+
+// load removes the expired and the malformed responses, rebuilds the index of the expirations
+// and computes the size of the responses kept.
+func (a *DiskAdapter) load() error {
+	now := time.Now().UnixNano()
+	return a.db.Update(func(tx *bolt.Tx) error {
+		if tx.Bucket(expirationsBucket) != nil {
+			if err := tx.DeleteBucket(expirationsBucket); err != nil {
+				return err
+			}
+		}
+		expirations, err := tx.CreateBucket(expirationsBucket)
+		if err != nil {
+			return err
+		}
+		responses, err := tx.CreateBucketIfNotExists(responsesBucket)
+		if err != nil {
+			return err
+		}
+		var stale [][]byte
+		err = responses.ForEach(func(k, v []byte) error {
+			if len(v) < 8 || int64(binary.BigEndian.Uint64(v)) <= now {
+				stale = append(stale, append([]byte(nil), k...))
+				return nil
+			}
+			a.size += len(v) - 8
+			return expirations.Put(expirationKey(v[:8], k), nil)
+		})
+		if err != nil {
+			return err
+		}
+		for _, k := range stale {
+			if err = responses.Delete(k); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// compact rewrites the file at path when less than half of it holds the responses,
+// as the pages freed by the evictions are reused but never returned to the file system.
+func (a *DiskAdapter) compact(path string) error {
+	info, err := os.Stat(path)
+	if err != nil || info.Size() <= 2*int64(a.size)+int64(a.db.Info().PageSize)*64 {
+		return err
+	}
+	compacted, err := bolt.Open(path+".compact", 0600, &bolt.Options{Timeout: time.Second})
+	if err != nil {
+		return err
+	}
+	if err = bolt.Compact(compacted, a.db, 64*1024*1024); err != nil {
+		_ = compacted.Close()
+		_ = os.Remove(path + ".compact")
+		return err
+	}
+	if err = compacted.Close(); err != nil {
+		return err
+	}
+	if err = a.db.Close(); err != nil {
+		return err
+	}
+	if err = os.Rename(path+".compact", path); err != nil {
+		return err
+	}
+	a.db, err = bolt.Open(path, 0600, &bolt.Options{Timeout: time.Second})
+	return err
+}
+
+// Get retrieves the cached response by a given key, reporting whether it exists and it is not expired.
+func (a *DiskAdapter) Get(key string) (response []byte, ok bool) {
+	a.mtx.Lock()
+	write, queued := a.pending[key]
+	a.mtx.Unlock()
+	if queued {
+		if write.release || !write.expiration.After(time.Now()) {
+			return nil, false
+		}
+		return write.response, true
+	}
+	now := time.Now().UnixNano()
+	_ = a.db.View(func(tx *bolt.Tx) error {
+		v := tx.Bucket(responsesBucket).Get([]byte(key))
+		if len(v) < 8 || int64(binary.BigEndian.Uint64(v)) <= now {
+			return nil
+		}
+		// the value is valid only during the transaction
+		response, ok = append([]byte(nil), v[8:]...), true
+		return nil
+	})
+	return
+}
+
+// Set queues a response for a given key until an expiration date, the responses larger than the capacity
+// only release the key.
+func (a *DiskAdapter) Set(key string, response []byte, expiration time.Time) {
+	if len(response) > a.capacity {
+		a.queue(key, diskWrite{release: true})
+		return
+	}
+	a.queue(key, diskWrite{response: response, expiration: expiration})
+}
+
+// Release queues the release of the cache for a given key.
+func (a *DiskAdapter) Release(key string) {
+	a.queue(key, diskWrite{release: true})
+}
+
+// queue adds the write of the key to the pending ones, replacing the previous one, and wakes up the writer.
+// The new keys are dropped while diskMaxPending writes are waiting for the disk.
+func (a *DiskAdapter) queue(key string, write diskWrite) {
+	a.mtx.Lock()
+	if _, ok := a.pending[key]; !ok && len(a.pending) >= diskMaxPending {
+		a.mtx.Unlock()
+		log.Printf("disk cache write %s dropped: %d writes pending", key, diskMaxPending)
+		return
+	}
+	a.seq++
+	write.seq = a.seq
+	a.pending[key] = write
+	a.mtx.Unlock()
+	select {
+	case a.wake <- struct{}{}:
+	default:
+	}
+}
+
+// write commits the pending writes each time they are queued, until the adapter is closed.
+func (a *DiskAdapter) write() {
+	defer close(a.done)
+	for {
+		select {
+		case <-a.wake:
+			a.flush()
+		case <-a.stop:
+			a.flush()
+			return
+		}
+	}
+}
+
+// flush commits the pending writes with a single transaction, evicting the responses closest to their expiration
+// when the adapter is full. The writes stay visible to Get until they are committed.
+func (a *DiskAdapter) flush() {
+	a.flushMtx.Lock()
+	defer a.flushMtx.Unlock()
+	a.mtx.Lock()
+	writes := make(map[string]diskWrite, len(a.pending))
+	for key, write := range a.pending {
+		writes[key] = write
+	}
+	size := a.size
+	a.mtx.Unlock()
+	if len(writes) == 0 {
+		return
+	}
+	err := a.db.Update(func(tx *bolt.Tx) error {
+		for key, write := range writes {
+			freed, err := remove(tx, []byte(key))
+			if size -= freed; err != nil {
+				return err
+			}
+			if write.release {
+				continue
+			}
+			if err = put(tx, []byte(key), write); err != nil {
+				return err
+			}
+			size += len(write.response)
+		}
+		cursor := tx.Bucket(expirationsBucket).Cursor()
+		for k, _ := cursor.First(); k != nil && size > a.capacity; k, _ = cursor.First() {
+			freed, err := remove(tx, append([]byte(nil), k[8:]...))
+			if size -= freed; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		log.Printf("disk cache write of %d responses: %s", len(writes), err)
+	}
+	a.mtx.Lock()
+	defer a.mtx.Unlock()
+	if err == nil {
+		a.size = size
+	}
+	// the writes queued again during the commit are kept for the next one
+	for key, write := range writes {
+		if pending, ok := a.pending[key]; ok && pending.seq == write.seq {
+			delete(a.pending, key)
+		}
+	}
+}
+
+// Close commits the pending writes and closes the file of the adapter.
+func (a *DiskAdapter) Close() error {
+	close(a.stop)
+	<-a.done
+	return a.db.Close()
+}
+
+// Size returns the bytes of the responses committed.
+func (a *DiskAdapter) Size() int {
+	a.mtx.Lock()
+	defer a.mtx.Unlock()
+	return a.size
+}
+
+// put stores the response of the write with its expiration and indexes it by the expiration.
+func put(tx *bolt.Tx, key []byte, write diskWrite) error {
+	v := make([]byte, 8+len(write.response))
+	binary.BigEndian.PutUint64(v, uint64(write.expiration.UnixNano()))
+	copy(v[8:], write.response)
+	if err := tx.Bucket(responsesBucket).Put(key, v); err != nil {
+		return err
+	}
+	return tx.Bucket(expirationsBucket).Put(expirationKey(v[:8], key), nil)
+}
+
+// remove deletes the response of the key, returning its size.
+func remove(tx *bolt.Tx, key []byte) (int, error) {
+	responses := tx.Bucket(responsesBucket)
+	v := responses.Get(key)
+	if v == nil {
+		return 0, nil
+	}
+	if len(v) < 8 {
+		return 0, responses.Delete(key)
+	}
+	size := len(v) - 8
+	if err := tx.Bucket(expirationsBucket).Delete(expirationKey(v[:8], key)); err != nil {
+		return 0, err
+	}
+	return size, responses.Delete(key)
+}
+
+// expirationKey returns the key of the expirations bucket, the expiration followed by the key.
+func expirationKey(expiration, key []byte) []byte {
+	return append(append(make([]byte, 0, len(expiration)+len(key)), expiration...), key...)
+}
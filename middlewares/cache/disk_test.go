@@ -0,0 +1,224 @@
+package cache
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+// tempCachePath returns the path of a cache file in a new temporary directory, removed by the returned function.
+func tempCachePath(t *testing.T) (string, func()) {
+	dir, err := ioutil.TempDir("", "cache")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return filepath.Join(dir, "cache.db"), func() { _ = os.RemoveAll(dir) }
+}
+
+func TestDiskAdapter(t *testing.T) {
+	path, remove := tempCachePath(t)
+	defer remove()
+	if _, err := NewDiskAdapter(path, 0); err == nil {
+		t.Error("Expected: the error of the capacity got : nil")
+	}
+	adapter, err := NewDiskAdapter(path, len("block 12")*2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer adapter.Close()
+	now := time.Now()
+	adapter.Set("/v1/block/12", []byte("block 12"), now.Add(time.Hour))
+	adapter.Set("/v1/block/13", []byte("block 13"), now.Add(2*time.Hour))
+	if got, ok := adapter.Get("/v1/block/12"); !ok || string(got) != "block 12" {
+		t.Errorf("Expected: block 12 got : %s %t", got, ok)
+	}
+	// the response closest to its expiration is evicted when the adapter is full, once the writes are committed
+	adapter.Set("/v1/block/14", []byte("block 14"), now.Add(3*time.Hour))
+	adapter.flush()
+	if _, ok := adapter.Get("/v1/block/12"); ok {
+		t.Error("Expected: the response closest to its expiration to be evicted got : found")
+	}
+	adapter.Release("/v1/block/13")
+	if _, ok := adapter.Get("/v1/block/13"); ok {
+		t.Error("Expected: the released response to be missing got : found")
+	}
+	// the responses larger than the capacity are not stored
+	adapter.Set("/v1/block/16", []byte("block 16 with its transactions"), now.Add(time.Hour))
+	if _, ok := adapter.Get("/v1/block/16"); ok {
+		t.Error("Expected: the response larger than the capacity to be missing got : found")
+	}
+}
+
+func TestDiskAdapter_expiration(t *testing.T) {
+	path, remove := tempCachePath(t)
+	defer remove()
+	adapter, err := NewDiskAdapter(path, 1024)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer adapter.Close()
+	adapter.Set("/v1/block/17", []byte("block 17"), time.Now().Add(time.Millisecond))
+	time.Sleep(5 * time.Millisecond)
+	if _, ok := adapter.Get("/v1/block/17"); ok {
+		t.Error("Expected: the expired response to be missing got : found")
+	}
+}
+
+func TestDiskAdapter_reopen(t *testing.T) {
+	path, remove := tempCachePath(t)
+	defer remove()
+	adapter, err := NewDiskAdapter(path, 1024)
+	if err != nil {
+		t.Fatal(err)
+	}
+	adapter.Set("/v1/block/14", []byte("block 14"), time.Now().Add(time.Hour))
+	adapter.Set("/v1/block/17", []byte("block 17"), time.Now().Add(time.Millisecond))
+	if err := adapter.Close(); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(5 * time.Millisecond)
+
+	// the responses survive the restart, except the expired ones
+	adapter, err = NewDiskAdapter(path, 1024)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer adapter.Close()
+	if got, ok := adapter.Get("/v1/block/14"); !ok || string(got) != "block 14" {
+		t.Errorf("Expected: block 14 got : %s %t", got, ok)
+	}
+	if _, ok := adapter.Get("/v1/block/17"); ok {
+		t.Error("Expected: the expired response to be missing got : found")
+	}
+	if size := adapter.Size(); size != len("block 14") {
+		t.Errorf("Expected: %d got : %d", len("block 14"), size)
+	}
+}
+
+func TestDiskAdapter_queue(t *testing.T) {
+	path, remove := tempCachePath(t)
+	defer remove()
+	adapter, err := NewDiskAdapter(path, 1<<20)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the concurrent writes are committed in the background, each one visible before its commit
+	var wg sync.WaitGroup
+	for i := 0; i < 64; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			key := "/v1/block/" + strconv.Itoa(i)
+			adapter.Set(key, []byte(key), time.Now().Add(time.Hour))
+			if got, ok := adapter.Get(key); !ok || string(got) != key {
+				t.Errorf("Expected: %s got : %s %t", key, got, ok)
+			}
+		}(i)
+	}
+	wg.Wait()
+	adapter.Release("/v1/block/0")
+	if _, ok := adapter.Get("/v1/block/0"); ok {
+		t.Error("Expected: the released response to be missing got : found")
+	}
+	if err := adapter.Close(); err != nil {
+		t.Fatal(err)
+	}
+	// the writes still pending are committed by Close
+	adapter, err = NewDiskAdapter(path, 1<<20)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer adapter.Close()
+	if _, ok := adapter.Get("/v1/block/0"); ok {
+		t.Error("Expected: the released response to be missing got : found")
+	}
+	if got, ok := adapter.Get("/v1/block/63"); !ok || string(got) != "/v1/block/63" {
+		t.Errorf("Expected: /v1/block/63 got : %s %t", got, ok)
+	}
+}
+
+func TestDiskAdapter_corrupted(t *testing.T) {
+	path, remove := tempCachePath(t)
+	defer remove()
+	if err := ioutil.WriteFile(path, []byte("not a bbolt file"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	// the corrupted file is replaced by an empty one
+	adapter, err := NewDiskAdapter(path, 1024)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer adapter.Close()
+	adapter.Set("/v1/block/12", []byte("block 12"), time.Now().Add(time.Hour))
+	if got, ok := adapter.Get("/v1/block/12"); !ok || string(got) != "block 12" {
+		t.Errorf("Expected: block 12 got : %s %t", got, ok)
+	}
+}
+
+func TestDiskAdapter_compact(t *testing.T) {
+	path, remove := tempCachePath(t)
+	defer remove()
+	adapter, err := NewDiskAdapter(path, 1<<20)
+	if err != nil {
+		t.Fatal(err)
+	}
+	response := make([]byte, 64*1024)
+	for i := 0; i < 16; i++ {
+		adapter.Set(string(rune('a'+i)), response, time.Now().Add(time.Hour))
+	}
+	// the responses reach the file before their release
+	adapter.flush()
+	for i := 1; i < 16; i++ {
+		adapter.Release(string(rune('a' + i)))
+	}
+	if err := adapter.Close(); err != nil {
+		t.Fatal(err)
+	}
+	before, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the space of the released responses is returned at the restart
+	adapter, err = NewDiskAdapter(path, 1<<20)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer adapter.Close()
+	after, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if after.Size() >= before.Size() {
+		t.Errorf("Expected: less than %d bytes got : %d", before.Size(), after.Size())
+	}
+	if got, ok := adapter.Get("a"); !ok || len(got) != len(response) {
+		t.Errorf("Expected: the response kept got : %d bytes %t", len(got), ok)
+	}
+}
+
+func TestTiered(t *testing.T) {
+	first, _ := NewMemoryAdapter(1024)
+	second, _ := NewMemoryAdapter(1024)
+	adapter := Tiered(first, second, time.Hour)
+	adapter.Set("/v1/latest", []byte("latest"), time.Now().Add(time.Second))
+	adapter.Set("/v1/block/12", []byte("block 12"), time.Now().Add(2*time.Hour))
+	if _, ok := second.Get("/v1/latest"); ok {
+		t.Error("Expected: the short lived response only in the first tier got : found in the second")
+	}
+	// the first tier is refilled by the second one, as after a restart
+	first.Release("/v1/block/12")
+	if got, ok := adapter.Get("/v1/block/12"); !ok || string(got) != "block 12" {
+		t.Errorf("Expected: block 12 got : %s %t", got, ok)
+	}
+	if _, ok := first.Get("/v1/block/12"); !ok {
+		t.Error("Expected: the response copied into the first tier got : missing")
+	}
+	adapter.Release("/v1/block/12")
+	if _, ok := second.Get("/v1/block/12"); ok {
+		t.Error("Expected: the response released from both the tiers got : found")
+	}
+}
@@ -0,0 +1,79 @@
+package cache
+
+import (
+	"container/list"
+	"errors"
+	"sync"
+	"time"
+)
+
+// MemoryAdapter is the in memory Adapter that evicts the least recently used responses
+// when their size exceeds its capacity.
+type MemoryAdapter struct {
+	mtx      sync.Mutex
+	capacity int
+	size     int
+	lru      *list.List
+	entries  map[string]*list.Element
+}
+
+type memoryEntry struct {
+	key        string
+	response   []byte
+	expiration time.Time
+}
+
+// NewMemoryAdapter returns the MemoryAdapter able to store up to capacity bytes of responses.
+func NewMemoryAdapter(capacity int) (*MemoryAdapter, error) {
+	if capacity <= 0 {
+		return nil, errors.New("memory adapter requires a capacity greater than 0")
+	}
+	return &MemoryAdapter{capacity: capacity, lru: list.New(), entries: make(map[string]*list.Element)}, nil
+}
+
+// Get retrieves the cached response by a given key, reporting whether it exists and it is not expired,
+// as the values stored directly, such as the blocks, are not checked by the Client.
+func (a *MemoryAdapter) Get(key string) ([]byte, bool) {
+	a.mtx.Lock()
+	defer a.mtx.Unlock()
+	element, ok := a.entries[key]
+	if !ok {
+		return nil, false
+	}
+	if !element.Value.(*memoryEntry).expiration.After(time.Now()) {
+		a.remove(key)
+		return nil, false
+	}
+	a.lru.MoveToFront(element)
+	return element.Value.(*memoryEntry).response, true
+}
+
+// Set caches a response for a given key until an expiration date.
+func (a *MemoryAdapter) Set(key string, response []byte, expiration time.Time) {
+	a.mtx.Lock()
+	defer a.mtx.Unlock()
+	a.remove(key)
+	if len(response) > a.capacity {
+		return
+	}
+	a.entries[key] = a.lru.PushFront(&memoryEntry{key: key, response: response, expiration: expiration})
+	a.size += len(response)
+	for a.size > a.capacity {
+		a.remove(a.lru.Back().Value.(*memoryEntry).key)
+	}
+}
+
+// Release frees the cache for a given key.
+func (a *MemoryAdapter) Release(key string) {
+	a.mtx.Lock()
+	a.remove(key)
+	a.mtx.Unlock()
+}
+
+func (a *MemoryAdapter) remove(key string) {
+	if element, ok := a.entries[key]; ok {
+		a.size -= len(element.Value.(*memoryEntry).response)
+		a.lru.Remove(element)
+		delete(a.entries, key)
+	}
+}
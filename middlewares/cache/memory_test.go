@@ -0,0 +1,64 @@
+package cache
+
+import (
+	"testing"
+	"time"
+)
+
+func TestMemoryAdapter(t *testing.T) {
+	if _, err := NewMemoryAdapter(0); err == nil {
+		t.Error("Expected: the error of the capacity got : nil")
+	}
+	adapter, err := NewMemoryAdapter(len("block 12") * 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expiration := time.Now().Add(time.Minute)
+	adapter.Set("/v1/block/12", []byte("block 12"), expiration)
+	adapter.Set("/v1/block/13", []byte("block 13"), expiration)
+	if got, ok := adapter.Get("/v1/block/12"); !ok || string(got) != "block 12" {
+		t.Errorf("Expected: block 12 got : %s %t", got, ok)
+	}
+	adapter.Release("/v1/block/12")
+	if _, ok := adapter.Get("/v1/block/12"); ok {
+		t.Error("Expected: the released response to be missing got : found")
+	}
+	// the least recently used response is evicted when the adapter is full
+	adapter.Set("/v1/block/14", []byte("block 14"), expiration)
+	adapter.Set("/v1/block/15", []byte("block 15"), expiration)
+	if _, ok := adapter.Get("/v1/block/13"); ok {
+		t.Error("Expected: the least recently used response to be evicted got : found")
+	}
+	if got, ok := adapter.Get("/v1/block/15"); !ok || string(got) != "block 15" {
+		t.Errorf("Expected: block 15 got : %s %t", got, ok)
+	}
+	// the responses larger than the capacity are not stored
+	adapter.Set("/v1/block/16", []byte("block 16 with its transactions"), expiration)
+	if _, ok := adapter.Get("/v1/block/16"); ok {
+		t.Error("Expected: the response larger than the capacity to be missing got : found")
+	}
+	// the expired responses are not returned
+	adapter.Set("/v1/block/17", []byte("block 17"), time.Now().Add(-time.Second))
+	if _, ok := adapter.Get("/v1/block/17"); ok {
+		t.Error("Expected: the expired response to be missing got : found")
+	}
+}
+
+func TestNamespace(t *testing.T) {
+	adapter, _ := NewMemoryAdapter(1024)
+	mainnet, sepolia := Namespace(adapter, "mainnet"), Namespace(adapter, "sepolia")
+	mainnet.Set("/v1/block/1", []byte("mainnet"), time.Now().Add(time.Minute))
+	if _, ok := sepolia.Get("/v1/block/1"); ok {
+		t.Error("Expected: no response in the other namespace got : a response")
+	}
+	if value, ok := mainnet.Get("/v1/block/1"); !ok || string(value) != "mainnet" {
+		t.Errorf("Expected: mainnet got : %s %t", value, ok)
+	}
+	if _, ok := adapter.Get("mainnet:/v1/block/1"); !ok {
+		t.Error("Expected: the prefixed key in the adapter got : none")
+	}
+	mainnet.Release("/v1/block/1")
+	if _, ok := mainnet.Get("/v1/block/1"); ok {
+		t.Error("Expected: the response released got : a response")
+	}
+}
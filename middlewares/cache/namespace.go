@@ -0,0 +1,39 @@
+package cache
+
+import "time"
+
+// namespace is the Adapter that keeps its keys apart from the others sharing the same storage.
+type namespace struct {
+	adapter Adapter
+	prefix  string
+}
+
+// Namespace returns the Adapter that stores into the adapter with the keys prefixed by the name,
+// so that the services sharing the same storage can not read each other responses.
+func Namespace(adapter Adapter, name string) Adapter {
+	return namespace{adapter: adapter, prefix: name + ":"}
+}
+
+// Get retrieves the cached response by a given key, reporting whether it exists.
+func (n namespace) Get(key string) ([]byte, bool) {
+	return n.adapter.Get(n.prefix + key)
+}
+
+// GetMulti retrieves the cached responses by the given keys, nil for the missing ones.
+func (n namespace) GetMulti(keys ...string) [][]byte {
+	prefixed := make([]string, len(keys))
+	for i, key := range keys {
+		prefixed[i] = n.prefix + key
+	}
+	return GetMulti(n.adapter, prefixed...)
+}
+
+// Set caches a response for a given key until an expiration date.
+func (n namespace) Set(key string, response []byte, expiration time.Time) {
+	n.adapter.Set(n.prefix+key, response, expiration)
+}
+
+// Release frees the cache for a given key.
+func (n namespace) Release(key string) {
+	n.adapter.Release(n.prefix + key)
+}
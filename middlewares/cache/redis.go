@@ -0,0 +1,102 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"github.com/go-redis/redis/v8"
+	"log"
+	"time"
+)
+
+// RedisAdapter is the Adapter that stores the responses in Redis, so that they are shared by the replicas
+// of the service, Redis expires them and evicts them by its own policy when it is full.
+type RedisAdapter struct {
+	client  *redis.Client
+	timeout time.Duration
+}
+
+// NewRedisAdapter connects to the Redis server at the url, such as redis://localhost:6379/0, with a pool
+// of up to poolSize connections, each command failing after the timeout. The server has to be reachable.
+func NewRedisAdapter(url string, poolSize int, timeout time.Duration) (*RedisAdapter, error) {
+	options, err := redis.ParseURL(url)
+	if err != nil {
+		return nil, err
+	}
+	options.PoolSize = poolSize
+	options.DialTimeout, options.ReadTimeout, options.WriteTimeout = timeout, timeout, timeout
+	a := &RedisAdapter{client: redis.NewClient(options), timeout: timeout}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	if err = a.client.Ping(ctx).Err(); err != nil {
+		_ = a.client.Close()
+		return nil, err
+	}
+	return a, nil
+}
+
+// Get retrieves the cached response by a given key, reporting whether it exists.
+func (a *RedisAdapter) Get(key string) ([]byte, bool) {
+	ctx, cancel := context.WithTimeout(context.Background(), a.timeout)
+	defer cancel()
+	response, err := a.client.Get(ctx, key).Bytes()
+	if err != nil {
+		if !errors.Is(err, redis.Nil) {
+			log.Printf("redis cache get %s: %s", key, err)
+		}
+		return nil, false
+	}
+	return response, true
+}
+
+// GetMulti retrieves the cached responses by the given keys with a single pipeline, nil for the missing ones.
+func (a *RedisAdapter) GetMulti(keys ...string) [][]byte {
+	responses := make([][]byte, len(keys))
+	if len(keys) == 0 {
+		return responses
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), a.timeout)
+	defer cancel()
+	cmds := make([]*redis.StringCmd, len(keys))
+	_, err := a.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for i, key := range keys {
+			cmds[i] = pipe.Get(ctx, key)
+		}
+		return nil
+	})
+	if err != nil && !errors.Is(err, redis.Nil) {
+		log.Printf("redis cache get of %d keys: %s", len(keys), err)
+	}
+	for i, cmd := range cmds {
+		if response, err := cmd.Bytes(); err == nil {
+			responses[i] = response
+		}
+	}
+	return responses
+}
+
+// Set caches a response for a given key until an expiration date, when it is not already expired.
+func (a *RedisAdapter) Set(key string, response []byte, expiration time.Time) {
+	ttl := time.Until(expiration)
+	if ttl <= 0 {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), a.timeout)
+	defer cancel()
+	if err := a.client.Set(ctx, key, response, ttl).Err(); err != nil {
+		log.Printf("redis cache set %s: %s", key, err)
+	}
+}
+
+// Release frees the cache for a given key.
+func (a *RedisAdapter) Release(key string) {
+	ctx, cancel := context.WithTimeout(context.Background(), a.timeout)
+	defer cancel()
+	if err := a.client.Del(ctx, key).Err(); err != nil {
+		log.Printf("redis cache release %s: %s", key, err)
+	}
+}
+
+// Close closes the connections of the adapter.
+func (a *RedisAdapter) Close() error {
+	return a.client.Close()
+}
@@ -0,0 +1,98 @@
+package cache
+
+import (
+	"github.com/alicebob/miniredis/v2"
+	"testing"
+	"time"
+)
+
+func TestRedisAdapter(t *testing.T) {
+	server, err := miniredis.Run()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer server.Close()
+	if _, err := NewRedisAdapter("localhost:6379", 1, time.Second); err == nil {
+		t.Error("Expected: the error of the invalid url got : nil")
+	}
+	adapter, err := NewRedisAdapter("redis://"+server.Addr(), 4, time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer adapter.Close()
+
+	adapter.Set("/v1/block/12", []byte("block 12"), time.Now().Add(time.Minute))
+	adapter.Set("/v1/block/13", []byte("block 13"), time.Now().Add(time.Hour))
+	if got, ok := adapter.Get("/v1/block/12"); !ok || string(got) != "block 12" {
+		t.Errorf("Expected: block 12 got : %s %t", got, ok)
+	}
+	// the responses expire in Redis
+	server.FastForward(2 * time.Minute)
+	if _, ok := adapter.Get("/v1/block/12"); ok {
+		t.Error("Expected: the expired response to be missing got : found")
+	}
+	// the responses already expired are not stored
+	adapter.Set("/v1/block/14", []byte("block 14"), time.Now().Add(-time.Second))
+	if server.Exists("/v1/block/14") {
+		t.Error("Expected: the expired response not to be stored got : stored")
+	}
+	got := adapter.GetMulti("/v1/block/12", "/v1/block/13", "/v1/block/14")
+	if len(got) != 3 || got[0] != nil || string(got[1]) != "block 13" || got[2] != nil {
+		t.Errorf("Expected: [nil block 13 nil] got : %q", got)
+	}
+	adapter.Release("/v1/block/13")
+	if _, ok := adapter.Get("/v1/block/13"); ok {
+		t.Error("Expected: the released response to be missing got : found")
+	}
+}
+
+func TestRedisAdapter_unreachable(t *testing.T) {
+	server, err := miniredis.Run()
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := server.Addr()
+	server.Close()
+	if _, err := NewRedisAdapter("redis://"+addr, 1, 100*time.Millisecond); err == nil {
+		t.Error("Expected: the error of the unreachable server got : nil")
+	}
+}
+
+func TestRedisAdapter_namespace(t *testing.T) {
+	server, err := miniredis.Run()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer server.Close()
+	redisAdapter, err := NewRedisAdapter("redis://"+server.Addr(), 4, time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer redisAdapter.Close()
+	// the replicas sharing the server read each other responses of the same chain
+	replica1 := Namespace(Namespace(redisAdapter, "infura"), "mainnet")
+	replica2 := Namespace(Namespace(redisAdapter, "infura"), "mainnet")
+	replica1.Set("/v1/block/12", []byte("block 12"), time.Now().Add(time.Minute))
+	if value, err := server.Get("infura:mainnet:/v1/block/12"); err != nil || value != "block 12" {
+		t.Errorf("Expected: block 12 got : %s %v", value, err)
+	}
+	got := GetMulti(replica2, "/v1/block/12", "/v1/block/13")
+	if len(got) != 2 || string(got[0]) != "block 12" || got[1] != nil {
+		t.Errorf("Expected: [block 12 nil] got : %q", got)
+	}
+}
+
+func TestGetMulti(t *testing.T) {
+	first, _ := NewMemoryAdapter(1024)
+	second, _ := NewMemoryAdapter(1024)
+	first.Set("/v1/block/12", []byte("block 12"), time.Now().Add(time.Hour))
+	second.Set("/v1/block/13", []byte("block 13"), time.Now().Add(time.Hour))
+	// the adapters not supporting it are read one key at a time
+	got := GetMulti(Tiered(first, second, time.Hour), "/v1/block/12", "/v1/block/13", "/v1/block/14")
+	if len(got) != 3 || string(got[0]) != "block 12" || string(got[1]) != "block 13" || got[2] != nil {
+		t.Errorf("Expected: [block 12 block 13 nil] got : %q", got)
+	}
+	if _, ok := first.Get("/v1/block/13"); !ok {
+		t.Error("Expected: the response copied into the first tier got : missing")
+	}
+}
@@ -0,0 +1,68 @@
+package cache
+
+import "time"
+
+// tiered is the Adapter that keeps every response in a fast first tier, and the ones cached for at least
+// minTTL also in a second tier, such as the DiskAdapter, that refills the first one after a restart.
+type tiered struct {
+	first  Adapter
+	second Adapter
+	minTTL time.Duration
+}
+
+// Tiered returns the Adapter that stores into first and, when they live for at least minTTL,
+// also into second, reading from second the responses missing from first.
+func Tiered(first, second Adapter, minTTL time.Duration) Adapter {
+	return tiered{first: first, second: second, minTTL: minTTL}
+}
+
+// Get retrieves the cached response by a given key from the first tier, or from the second one
+// copying it into the first, reporting whether it exists.
+func (t tiered) Get(key string) ([]byte, bool) {
+	if response, ok := t.first.Get(key); ok {
+		return response, true
+	}
+	response, ok := t.second.Get(key)
+	if ok {
+		// only the responses living for at least minTTL reach the second tier
+		t.first.Set(key, response, time.Now().Add(t.minTTL))
+	}
+	return response, ok
+}
+
+// GetMulti retrieves the cached responses by the given keys from the first tier, and the missing ones
+// from the second one copying them into the first, nil for the responses missing from both.
+func (t tiered) GetMulti(keys ...string) [][]byte {
+	responses := GetMulti(t.first, keys...)
+	var missing []string
+	var positions []int
+	for i, response := range responses {
+		if response == nil {
+			missing, positions = append(missing, keys[i]), append(positions, i)
+		}
+	}
+	if len(missing) == 0 {
+		return responses
+	}
+	for i, response := range GetMulti(t.second, missing...) {
+		if response != nil {
+			responses[positions[i]] = response
+			t.first.Set(missing[i], response, time.Now().Add(t.minTTL))
+		}
+	}
+	return responses
+}
+
+// Set caches a response for a given key until an expiration date, in the second tier only when it lives for at least minTTL.
+func (t tiered) Set(key string, response []byte, expiration time.Time) {
+	t.first.Set(key, response, expiration)
+	if time.Until(expiration) >= t.minTTL {
+		t.second.Set(key, response, expiration)
+	}
+}
+
+// Release frees the cache for a given key in both the tiers.
+func (t tiered) Release(key string) {
+	t.first.Release(key)
+	t.second.Release(key)
+}